@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/wowsql/wowsql-go/WOWSQL"
+	"github.com/wowsql/wowsql-go/wowsql"
 )
 
 func main() {
@@ -198,14 +198,14 @@ func main() {
 
 	// 8. Delete multiple files
 	fmt.Println("8. Delete multiple files")
-	err = storage.DeleteFiles([]string{
+	deleteResult, err := storage.DeleteFiles([]string{
 		"uploads/file1.txt",
 		"uploads/file2.txt",
 	})
 	if err != nil {
 		log.Fatalf("Failed to delete files: %v", err)
 	}
-	fmt.Println("Multiple files deleted")
+	fmt.Printf("Deleted %d file(s), %d failed\n", len(deleteResult.Deleted), len(deleteResult.Errors))
 
 	// 9. Check API health
 	fmt.Println("9. Check API health")
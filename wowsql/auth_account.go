@@ -0,0 +1,205 @@
+package WOWSQL
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrReauthenticationRequired is returned by DeleteAccount when the server
+// wants the user to sign in again before the account can be deleted.
+var ErrReauthenticationRequired = errors.New("reauthentication required")
+
+// RequestEmailChange starts an email change for the signed-in user. The
+// server sends a confirmation link to newEmail. If the address is already
+// registered the returned error matches errors.Is(err, ErrConflict).
+func (c *AuthClient) RequestEmailChange(newEmail string) error {
+	return c.RequestEmailChangeContext(context.Background(), newEmail)
+}
+
+// RequestEmailChangeContext is like RequestEmailChange but uses ctx for cancellation and deadlines.
+func (c *AuthClient) RequestEmailChangeContext(ctx context.Context, newEmail string) error {
+	if strings.TrimSpace(newEmail) == "" {
+		return fmt.Errorf("newEmail is required")
+	}
+
+	headers, err := c.bearerHeaders("change email")
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"new_email": newEmail,
+	}
+
+	_, err = c.doRequest(ctx, "POST", "/change-email", payload, headers)
+	if err != nil && errors.Is(err, ErrConflict) {
+		return fmt.Errorf("email %s is already in use: %w", newEmail, err)
+	}
+	return err
+}
+
+// ConfirmEmailChange finalizes an email change using the token from the
+// confirmation link. If the server issues a new session reflecting the new
+// email, it replaces the stored session.
+func (c *AuthClient) ConfirmEmailChange(token string) error {
+	return c.ConfirmEmailChangeContext(context.Background(), token)
+}
+
+// ConfirmEmailChangeContext is like ConfirmEmailChange but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ConfirmEmailChangeContext(ctx context.Context, token string) error {
+	if token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	payload := map[string]interface{}{
+		"token": token,
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/change-email/confirm", payload, nil)
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return fmt.Errorf("new email is already in use: %w", err)
+		}
+		return err
+	}
+
+	var resp authResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse confirm email change response: %w", err)
+	}
+	if resp.AccessToken == "" {
+		// The session is unchanged, but cached profiles still show the old email.
+		c.InvalidateUserCache()
+		return nil
+	}
+
+	_, err = c.sessionFromAuthResponse(body, "confirm email change")
+	return err
+}
+
+// DeleteAccount permanently deletes the signed-in user's account and clears
+// the local session. confirmation is passed to the server, which may require
+// the user's password or a typed confirmation phrase; pass "" if it does not.
+// If the session is too old or the confirmation is wrong, the error matches
+// ErrReauthenticationRequired and the session is kept so the app can prompt
+// the user to sign in again.
+// ⚠️ WARNING: This operation cannot be undone!
+func (c *AuthClient) DeleteAccount(confirmation string) error {
+	return c.DeleteAccountContext(context.Background(), confirmation)
+}
+
+// DeleteAccountContext is like DeleteAccount but uses ctx for cancellation and deadlines.
+func (c *AuthClient) DeleteAccountContext(ctx context.Context, confirmation string) error {
+	headers, err := c.bearerHeaders("delete account")
+	if err != nil {
+		return err
+	}
+
+	var payload interface{}
+	if confirmation != "" {
+		payload = map[string]interface{}{
+			"confirmation": confirmation,
+		}
+	}
+
+	if _, err := c.doRequest(ctx, "DELETE", "/me", payload, headers); err != nil {
+		var apiErr *WOWSQLError
+		if errors.Is(err, ErrUnauthorized) || (errors.As(err, &apiErr) && apiErr.Code == "reauthentication_required") {
+			return fmt.Errorf("failed to delete account: %w: %w", ErrReauthenticationRequired, err)
+		}
+		return err
+	}
+
+	c.ClearSession()
+	return nil
+}
+
+// DefaultVerificationPollInterval is how often WaitForVerification polls when
+// no interval is given.
+const DefaultVerificationPollInterval = 3 * time.Second
+
+// WaitForVerification blocks until email has been verified or timeout
+// elapses, polling every interval, e.g. in a CLI waiting for the user to
+// click a magic or verification link. With a signed-in session it polls the
+// user profile until EmailVerified is set; otherwise it polls the
+// verification status of email and, once it is verified, returns a result
+// with an empty Session; the caller then has to sign in. Transient errors are
+// retried until the timeout. On timeout the error matches
+// context.DeadlineExceeded.
+func (c *AuthClient) WaitForVerification(email string, timeout, interval time.Duration) (*AuthResult, error) {
+	return c.WaitForVerificationContext(context.Background(), email, timeout, interval)
+}
+
+// WaitForVerificationContext is like WaitForVerification but uses ctx for cancellation and deadlines.
+func (c *AuthClient) WaitForVerificationContext(ctx context.Context, email string, timeout, interval time.Duration) (*AuthResult, error) {
+	if strings.TrimSpace(email) == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	if interval <= 0 {
+		interval = DefaultVerificationPollInterval
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		result, err := c.pollVerification(ctx, email)
+		if result != nil {
+			return result, nil
+		}
+		if err != nil && !isRetryableError(err) {
+			return nil, err
+		}
+		if sleepErr := sleepContext(ctx, interval); sleepErr != nil {
+			return nil, fmt.Errorf("email %s was not verified in time: %w", email, sleepErr)
+		}
+	}
+}
+
+// pollVerification checks once whether email is verified. It returns a nil
+// result while it is not.
+func (c *AuthClient) pollVerification(ctx context.Context, email string) (*AuthResult, error) {
+	c.mu.RLock()
+	signedIn := c.accessToken != ""
+	c.mu.RUnlock()
+
+	if signedIn {
+		user, err := c.GetUserFreshContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(user.Email, email) {
+			return nil, fmt.Errorf("signed-in user is %s, not %s", user.Email, email)
+		}
+		if !user.EmailVerified {
+			return nil, nil
+		}
+		return &AuthResult{User: user, Session: c.GetSession()}, nil
+	}
+
+	body, err := c.doRequest(ctx, "GET", "/verification-status?email="+url.QueryEscape(email), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The status is looked up by email alone, so any tokens in the response
+	// are ignored: the caller has to sign in to get a session.
+	var status struct {
+		Verified bool      `json:"verified"`
+		User     *AuthUser `json:"user"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse verification status response: %w", err)
+	}
+	if !status.Verified {
+		return nil, nil
+	}
+	return &AuthResult{User: status.User}, nil
+}
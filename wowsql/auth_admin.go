@@ -0,0 +1,224 @@
+package WOWSQL
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AdminUserList is one page of AdminListUsers. Total is -1 when the server
+// did not report it.
+type AdminUserList struct {
+	Users   []AuthUser
+	Total   int
+	Page    int
+	PerPage int
+	// NextPage and PrevPage are the neighbouring page numbers, or 0 if there
+	// is no such page or the server did not say.
+	NextPage int
+	PrevPage int
+}
+
+// TotalPages returns the number of pages, or -1 if the total is unknown.
+func (l *AdminUserList) TotalPages() int {
+	if l.Total < 0 || l.PerPage <= 0 {
+		return -1
+	}
+	return (l.Total + l.PerPage - 1) / l.PerPage
+}
+
+// HasNextPage reports whether another page follows this one.
+func (l *AdminUserList) HasNextPage() bool {
+	if l.NextPage > 0 {
+		return true
+	}
+	if pages := l.TotalPages(); pages >= 0 {
+		return l.Page < pages
+	}
+	return false
+}
+
+// AdminListUsers lists project users one page at a time (pages start at 1).
+// Pagination details are read from the response body, falling back to the
+// X-Total-Count and Link headers.
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func (c *AuthClient) AdminListUsers(page, perPage int) (*AdminUserList, error) {
+	return c.AdminListUsersContext(context.Background(), page, perPage)
+}
+
+// AdminListUsersContext is like AdminListUsers but uses ctx for cancellation and deadlines.
+func (c *AuthClient) AdminListUsersContext(ctx context.Context, page, perPage int) (*AdminUserList, error) {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", fmt.Sprint(page))
+	}
+	if perPage > 0 {
+		query.Set("per_page", fmt.Sprint(perPage))
+	}
+	path := "/admin/users"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	body, header, err := c.doRequestWithHeaders(ctx, "GET", path, nil, nil)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	var resp struct {
+		Users   []AuthUser `json:"users"`
+		Total   *int       `json:"total"`
+		Page    int        `json:"page"`
+		PerPage int        `json:"per_page"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse list users response: %w", err)
+	}
+
+	result := &AdminUserList{
+		Users:   resp.Users,
+		Total:   -1,
+		Page:    resp.Page,
+		PerPage: resp.PerPage,
+	}
+	if resp.Total != nil {
+		result.Total = *resp.Total
+	} else if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		result.Total = total
+	}
+	if result.Page == 0 {
+		result.Page = page
+		if result.Page <= 0 {
+			result.Page = 1
+		}
+	}
+	if result.PerPage == 0 {
+		result.PerPage = perPage
+	}
+	links := parseLinkHeader(header.Get("Link"))
+	result.NextPage = pageParam(links["next"])
+	result.PrevPage = pageParam(links["prev"])
+
+	return result, nil
+}
+
+// parseLinkHeader maps the rel of each link in an RFC 8288 Link header to
+// its URL.
+func parseLinkHeader(value string) map[string]string {
+	links := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		segments := strings.Split(part, ";")
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		target = target[1 : len(target)-1]
+		for _, param := range segments[1:] {
+			name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(name) != "rel" {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+				links[rel] = target
+			}
+		}
+	}
+	return links
+}
+
+// pageParam returns the page query parameter of link, or 0.
+func pageParam(link string) int {
+	if link == "" {
+		return 0
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0
+	}
+	page, _ := strconv.Atoi(u.Query().Get("page"))
+	return page
+}
+
+// AdminGetUserByID fetches a single user by ID.
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func (c *AuthClient) AdminGetUserByID(id string) (*AuthUser, error) {
+	return c.AdminGetUserByIDContext(context.Background(), id)
+}
+
+// AdminGetUserByIDContext is like AdminGetUserByID but uses ctx for cancellation and deadlines.
+func (c *AuthClient) AdminGetUserByIDContext(ctx context.Context, id string) (*AuthUser, error) {
+	if id == "" {
+		return nil, fmt.Errorf("user id is required")
+	}
+
+	body, err := c.doRequest(ctx, "GET", "/admin/users/"+url.PathEscape(id), nil, nil)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	var user AuthUser
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse user response: %w", err)
+	}
+
+	return &user, nil
+}
+
+// AdminCreateUser creates a user directly, without sending a confirmation email.
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func (c *AuthClient) AdminCreateUser(email, password string, metadata map[string]interface{}) (*AuthUser, error) {
+	return c.AdminCreateUserContext(context.Background(), email, password, metadata)
+}
+
+// AdminCreateUserContext is like AdminCreateUser but uses ctx for cancellation and deadlines.
+func (c *AuthClient) AdminCreateUserContext(ctx context.Context, email, password string, metadata map[string]interface{}) (*AuthUser, error) {
+	payload := map[string]interface{}{
+		"email":    email,
+		"password": password,
+	}
+	if metadata != nil {
+		payload["user_metadata"] = metadata
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/admin/users", payload, nil)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	var user AuthUser
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse create user response: %w", err)
+	}
+
+	return &user, nil
+}
+
+// AdminDeleteUser permanently deletes a user.
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+// ⚠️ WARNING: This operation cannot be undone!
+func (c *AuthClient) AdminDeleteUser(id string) error {
+	return c.AdminDeleteUserContext(context.Background(), id)
+}
+
+// AdminDeleteUserContext is like AdminDeleteUser but uses ctx for cancellation and deadlines.
+func (c *AuthClient) AdminDeleteUserContext(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("user id is required")
+	}
+
+	_, err := c.doRequest(ctx, "DELETE", "/admin/users/"+url.PathEscape(id), nil, nil)
+	return adminError(err)
+}
+
+// adminError explains a 403 from an admin endpoint, which almost always means
+// the client was built with an anonymous key.
+func adminError(err error) error {
+	if err != nil && errors.Is(err, ErrForbidden) {
+		return fmt.Errorf("admin user management requires a SERVICE ROLE key. You are using an anonymous key which cannot manage users: %w", err)
+	}
+	return err
+}
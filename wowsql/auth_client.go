@@ -2,12 +2,18 @@ package WOWSQL
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,17 +30,54 @@ type AuthConfig struct {
 	APIKey string
 	// Deprecated: Use APIKey instead. Kept for backward compatibility.
 	PublicAPIKey string
+	// AutoRefresh makes GetUser refresh the session automatically when the
+	// access token is about to expire.
+	AutoRefresh bool
+	// RefreshSkew is how long before expiry a token is considered stale.
+	// Defaults to 60 seconds.
+	RefreshSkew time.Duration
+	// SessionStore persists the session across restarts. When set, the stored
+	// session is loaded by NewAuthClient and every session change is saved.
+	SessionStore SessionStore
+	// UserAgent identifies the calling application in server logs.
+	UserAgent string
+	// DefaultHeaders are sent with every request, e.g. correlation or tenant
+	// IDs. Headers passed to an individual call take precedence.
+	DefaultHeaders map[string]string
 }
 
 // AuthClient handles project-level authentication endpoints.
 // UNIFIED AUTHENTICATION: Uses the same API keys (anon/service) as database operations.
 type AuthClient struct {
-	baseURL     string
-	httpClient  *http.Client
-	apiKey      string // Unified API key (anon or service)
-	publicKey   string // Deprecated: same as apiKey, kept for backward compatibility
-	accessToken string
+	baseURL      string // project URL followed by basePath
+	basePath     string
+	httpClient   *http.Client
+	apiKey       string       // Unified API key (anon or service)
+	publicKey    string       // Deprecated: same as apiKey, kept for backward compatibility
+	mu           sync.RWMutex // guards apiKey, publicKey, accessToken, refreshToken and expiresAt
+	accessToken  string
 	refreshToken string
+	expiresAt    time.Time
+	autoRefresh  bool
+	refreshSkew  time.Duration
+	retry        *RetryPolicy
+	sessionStore SessionStore
+	userAgent    string
+	headers      map[string]string
+	userOnSignIn bool
+
+	oauthCallbackTimeout time.Duration
+	interceptors         []RequestInterceptor
+
+	// oauthFlows keeps pending OAuth states when the session store cannot.
+	oauthFlows *oauthFlowCache
+
+	listenersMu    sync.Mutex
+	listeners      []authStateListener
+	nextListenerID int
+
+	userCache userCache
+	jwks      jwksCache
 }
 
 // AuthUser represents an authenticated user.
@@ -44,9 +87,14 @@ type AuthUser struct {
 	FullName      string                 `json:"full_name,omitempty"`
 	AvatarURL     string                 `json:"avatar_url,omitempty"`
 	EmailVerified bool                   `json:"email_verified"`
+	Phone         string                 `json:"phone,omitempty"`
+	PhoneVerified bool                   `json:"phone_verified"`
+	Role          string                 `json:"role,omitempty"`
+	Locale        string                 `json:"locale,omitempty"`
 	UserMetadata  map[string]interface{} `json:"user_metadata"`
 	AppMetadata   map[string]interface{} `json:"app_metadata"`
 	CreatedAt     string                 `json:"created_at,omitempty"`
+	LastSignInAt  string                 `json:"last_sign_in_at,omitempty"`
 }
 
 // AuthSession represents session tokens.
@@ -55,6 +103,24 @@ type AuthSession struct {
 	RefreshToken string `json:"refresh_token"`
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
+	// ExpiresAt is when the access token expires, or the zero time if
+	// unknown. It is kept by session stores, unlike the relative ExpiresIn.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsExpired reports whether the access token has expired. It is false when
+// the expiry is unknown.
+func (s AuthSession) IsExpired() bool {
+	return !s.ExpiresAt.IsZero() && !time.Now().Before(s.ExpiresAt)
+}
+
+// ExpiresInDuration returns the time left until the access token expires,
+// which is negative once it has expired, or 0 if the expiry is unknown.
+func (s AuthSession) ExpiresInDuration() time.Duration {
+	if s.ExpiresAt.IsZero() {
+		return 0
+	}
+	return time.Until(s.ExpiresAt)
 }
 
 // AuthResult combines user (if available) with session tokens.
@@ -70,18 +136,29 @@ type OAuthAuthorizeResponse struct {
 	RedirectURI         string `json:"redirect_uri"`
 	BackendCallbackURL  string `json:"backend_callback_url,omitempty"`
 	FrontendRedirectURI string `json:"frontend_redirect_uri,omitempty"`
+	// State is the CSRF token included in the authorization URL. The client
+	// keeps the flow under it until ExchangeOAuthCallbackWithState consumes it.
+	State string `json:"state,omitempty"`
 }
 
 type signUpRequest struct {
 	Email        string                 `json:"email"`
 	Password     string                 `json:"password"`
 	FullName     *string                `json:"full_name,omitempty"`
+	Phone        *string                `json:"phone,omitempty"`
+	AvatarURL    *string                `json:"avatar_url,omitempty"`
+	Locale       *string                `json:"locale,omitempty"`
 	UserMetadata map[string]interface{} `json:"user_metadata,omitempty"`
+	CaptchaToken *string                `json:"captcha_token,omitempty"`
+
+	idempotencyKey      string
+	requireVerification bool
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email        string  `json:"email"`
+	Password     string  `json:"password"`
+	CaptchaToken *string `json:"captcha_token,omitempty"`
 }
 
 type authResponse struct {
@@ -90,6 +167,8 @@ type authResponse struct {
 	RefreshToken string    `json:"refresh_token"`
 	TokenType    string    `json:"token_type"`
 	ExpiresIn    int       `json:"expires_in"`
+	// Set by sign-up when the project requires a verified email first.
+	VerificationRequired bool `json:"verification_required,omitempty"`
 }
 
 type loginResponse struct {
@@ -97,6 +176,10 @@ type loginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
+	// Set instead of tokens when a second factor is required.
+	MFARequired bool     `json:"mfa_required,omitempty"`
+	ChallengeID string   `json:"challenge_id,omitempty"`
+	Factors     []string `json:"factors,omitempty"`
 }
 
 // NewAuthClient constructs a new project auth client.
@@ -114,18 +197,166 @@ func NewAuthClient(config AuthConfig) *AuthClient {
 		unifiedKey = config.PublicAPIKey
 	}
 
-	return &AuthClient{
-		baseURL:   base,
-		apiKey:    unifiedKey,
-		publicKey: unifiedKey, // Keep for backward compatibility
+	refreshSkew := config.RefreshSkew
+	if refreshSkew == 0 {
+		refreshSkew = 60 * time.Second
+	}
+
+	client := &AuthClient{
+		baseURL:      base,
+		basePath:     DefaultAuthBasePath,
+		apiKey:       unifiedKey,
+		publicKey:    unifiedKey, // Keep for backward compatibility
+		autoRefresh:  config.AutoRefresh,
+		refreshSkew:  refreshSkew,
+		sessionStore: config.SessionStore,
+		userAgent:    config.UserAgent,
+		headers:      make(map[string]string, len(config.DefaultHeaders)),
+		oauthFlows:   &oauthFlowCache{},
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
 	}
+
+	for k, v := range config.DefaultHeaders {
+		client.headers[k] = v
+	}
+
+	if client.sessionStore != nil {
+		if session, err := client.sessionStore.Load(); err == nil {
+			client.accessToken = session.AccessToken
+			client.refreshToken = session.RefreshToken
+			client.expiresAt = session.ExpiresAt
+			if client.expiresAt.IsZero() {
+				// Sessions saved before ExpiresAt existed
+				client.expiresAt = sessionExpiry(session.AccessToken, 0)
+			}
+		}
+	}
+
+	return client
+}
+
+// WithRetry enables retries with exponential backoff and jitter for
+// transient failures (network errors, 429, 502, 503 and 504). Only idempotent
+// requests such as GET are retried, so sign-up and login are never sent twice,
+// except a SignUp given WithSignUpIdempotencyKey.
+// A Retry-After header from the server takes precedence over the computed delay.
+// Call it before the client is shared between goroutines.
+func (c *AuthClient) WithRetry(maxAttempts int, baseDelay time.Duration) *AuthClient {
+	c.retry = &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+	}
+	return c
+}
+
+// SetTimeout sets the timeout for each request, replacing AuthConfig.Timeout.
+// Calls made with a context that has a deadline use that deadline instead.
+// Call it before the client is shared between goroutines.
+func (c *AuthClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetBasePath sets the path the auth API is mounted under, replacing
+// DefaultAuthBasePath, e.g. for a self-hosted backend. An empty basePath
+// restores the default. Call it before the client is shared between
+// goroutines.
+func (c *AuthClient) SetBasePath(basePath string) {
+	basePath = normalizeBasePath(basePath)
+	if basePath == "" {
+		basePath = DefaultAuthBasePath
+	}
+	c.baseURL = strings.TrimSuffix(c.baseURL, c.basePath) + basePath
+	c.basePath = basePath
 }
 
-// SignUp registers a new end user for the project.
+// WithOAuthCallbackTimeout bounds the whole OAuth callback exchange,
+// including its retry, replacing the client timeout for those calls. Keep it
+// short: the user is waiting on the redirect. Zero uses the client timeout.
+// Call it before the client is shared between goroutines.
+func (c *AuthClient) WithOAuthCallbackTimeout(timeout time.Duration) *AuthClient {
+	c.oauthCallbackTimeout = timeout
+	return c
+}
+
+// WithRequestInterceptor adds fn to the interceptors run on every request
+// before it is sent, in the order they were added. A retried request is
+// intercepted again. Call it before the client is shared between goroutines.
+func (c *AuthClient) WithRequestInterceptor(fn RequestInterceptor) *AuthClient {
+	c.interceptors = append(c.interceptors, fn)
+	return c
+}
+
+// WithUserOnSignIn makes SignIn and SignInWithCaptcha fetch the user profile
+// after storing the session, so the returned AuthResult.User is set. It costs
+// one extra request per sign-in. Call it before the client is shared between
+// goroutines.
+func (c *AuthClient) WithUserOnSignIn(enabled bool) *AuthClient {
+	c.userOnSignIn = enabled
+	return c
+}
+
+// SetAPIKey replaces the API key sent with every request, e.g. when keys are
+// rotated. It is safe to call while other goroutines use the client; the
+// current session is kept.
+func (c *AuthClient) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = key
+	c.publicKey = key
+}
+
+// WithAccessToken returns a copy of c that acts as the user the access token
+// belongs to, for servers that handle many users with one configured client.
+// The copy shares the HTTP client, API key and settings of c but has its own
+// session: it starts with token and no refresh token, never writes to the
+// session store, has no listeners and does not refresh automatically. c is
+// left unchanged. Keys set on c later with SetAPIKey do not reach the copy.
+func (c *AuthClient) WithAccessToken(token string) *AuthClient {
+	c.mu.RLock()
+	apiKey, publicKey := c.apiKey, c.publicKey
+	c.mu.RUnlock()
+	c.userCache.mu.Lock()
+	userCacheTTL := c.userCache.ttl
+	c.userCache.mu.Unlock()
+	c.jwks.mu.Lock()
+	jwksTTL := c.jwks.ttl
+	c.jwks.mu.Unlock()
+
+	clone := &AuthClient{
+		baseURL:              c.baseURL,
+		basePath:             c.basePath,
+		httpClient:           c.httpClient,
+		apiKey:               apiKey,
+		publicKey:            publicKey,
+		accessToken:          token,
+		expiresAt:            sessionExpiry(token, 0),
+		refreshSkew:          c.refreshSkew,
+		retry:                c.retry,
+		userAgent:            c.userAgent,
+		headers:              c.headers,
+		userOnSignIn:         c.userOnSignIn,
+		oauthCallbackTimeout: c.oauthCallbackTimeout,
+		interceptors:         c.interceptors[:len(c.interceptors):len(c.interceptors)],
+		oauthFlows:           c.oauthFlows,
+	}
+	clone.userCache.ttl = userCacheTTL
+	clone.jwks.ttl = jwksTTL
+	return clone
+}
+
+// SignUp registers a new end user for the project. Each call is sent with an
+// Idempotency-Key header. It is retried under WithRetry only when the key is
+// set with WithSignUpIdempotencyKey.
+// When the email must be verified first, the error is an *UnverifiedError and
+// the result still carries the new user; see WithRequireVerification.
 func (c *AuthClient) SignUp(email, password string, options ...func(*signUpRequest)) (*AuthResult, error) {
+	return c.SignUpContext(context.Background(), email, password, options...)
+}
+
+// SignUpContext is like SignUp but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignUpContext(ctx context.Context, email, password string, options ...func(*signUpRequest)) (*AuthResult, error) {
 	payload := &signUpRequest{
 		Email:    email,
 		Password: password,
@@ -133,8 +364,15 @@ func (c *AuthClient) SignUp(email, password string, options ...func(*signUpReque
 	for _, opt := range options {
 		opt(payload)
 	}
+	if payload.idempotencyKey == "" {
+		payload.idempotencyKey = NewIdempotencyKey()
+	} else {
+		// The caller vouches that the backend deduplicates on their key.
+		ctx = withRetrySafe(ctx)
+	}
+	headers := map[string]string{IdempotencyKeyHeader: payload.idempotencyKey}
 
-	body, err := c.doRequest("POST", "/signup", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/signup", payload, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -144,13 +382,23 @@ func (c *AuthClient) SignUp(email, password string, options ...func(*signUpReque
 		return nil, fmt.Errorf("failed to parse signup response: %w", err)
 	}
 
+	verified := resp.User != nil && resp.User.EmailVerified
+	if resp.VerificationRequired || (payload.requireVerification && !verified) {
+		return &AuthResult{User: resp.User}, &UnverifiedError{
+			WOWSQLError: WOWSQLError{Message: "email verification is required before signing in", Code: "email_not_verified"},
+			Email:       email,
+		}
+	}
+
 	session := AuthSession{
 		AccessToken:  resp.AccessToken,
 		RefreshToken: resp.RefreshToken,
 		TokenType:    resp.TokenType,
 		ExpiresIn:    resp.ExpiresIn,
 	}
-	c.persistSession(session)
+	if err := c.persistSession(&session, AuthEventSignedIn); err != nil {
+		return nil, err
+	}
 
 	return &AuthResult{
 		User:    resp.User,
@@ -165,6 +413,29 @@ func WithFullName(fullName string) func(*signUpRequest) {
 	}
 }
 
+// WithPhone sets the user's phone number for SignUp, preferably in E.164
+// format such as "+14155550123".
+func WithPhone(phone string) func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.Phone = &phone
+	}
+}
+
+// WithAvatarURL sets the user's avatar URL for SignUp.
+func WithAvatarURL(avatarURL string) func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.AvatarURL = &avatarURL
+	}
+}
+
+// WithLocale sets the user's preferred locale for SignUp as a BCP 47 tag,
+// e.g. "en-US". The server may use it to localize emails.
+func WithLocale(locale string) func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.Locale = &locale
+	}
+}
+
 // WithUserMetadata sets optional metadata for SignUp.
 func WithUserMetadata(metadata map[string]interface{}) func(*signUpRequest) {
 	return func(req *signUpRequest) {
@@ -172,22 +443,102 @@ func WithUserMetadata(metadata map[string]interface{}) func(*signUpRequest) {
 	}
 }
 
-// SignIn authenticates an existing user.
+// WithCaptchaToken sets the captcha (hCaptcha/Turnstile) token for SignUp on
+// projects with bot protection enabled.
+func WithCaptchaToken(token string) func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.CaptchaToken = &token
+	}
+}
+
+// WithSignUpIdempotencyKey sets the Idempotency-Key header of the sign-up
+// request instead of a generated one, e.g. so it can be logged. Use
+// NewIdempotencyKey to create one. It also lets WithRetry retry the sign-up,
+// so only use it if the backend deduplicates sign-ups on the key; otherwise a
+// retried sign-up may be processed twice.
+func WithSignUpIdempotencyKey(key string) func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.idempotencyKey = key
+	}
+}
+
+// WithRequireVerification makes SignUp keep the user signed out until their
+// email is verified: unless the server reports the email as already verified,
+// no session is stored and SignUp returns an *UnverifiedError alongside the
+// result. Without it, SignUp does the same only when the server says
+// verification is required.
+func WithRequireVerification() func(*signUpRequest) {
+	return func(req *signUpRequest) {
+		req.requireVerification = true
+	}
+}
+
+// SignIn authenticates an existing user. The returned User is nil unless
+// WithUserOnSignIn is enabled.
 func (c *AuthClient) SignIn(email, password string) (*AuthResult, error) {
-	payload := loginRequest{
+	return c.SignInContext(context.Background(), email, password)
+}
+
+// SignInContext is like SignIn but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignInContext(ctx context.Context, email, password string) (*AuthResult, error) {
+	return c.signIn(ctx, loginRequest{
 		Email:    email,
 		Password: password,
+	})
+}
+
+// SignInWithCaptcha authenticates an existing user on projects with captcha
+// protection enabled.
+func (c *AuthClient) SignInWithCaptcha(email, password, captchaToken string) (*AuthResult, error) {
+	return c.SignInWithCaptchaContext(context.Background(), email, password, captchaToken)
+}
+
+// SignInWithCaptchaContext is like SignInWithCaptcha but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignInWithCaptchaContext(ctx context.Context, email, password, captchaToken string) (*AuthResult, error) {
+	if captchaToken == "" {
+		return nil, fmt.Errorf("captchaToken is required")
 	}
 
-	body, err := c.doRequest("POST", "/login", payload, nil)
+	return c.signIn(ctx, loginRequest{
+		Email:        email,
+		Password:     password,
+		CaptchaToken: &captchaToken,
+	})
+}
+
+func (c *AuthClient) signIn(ctx context.Context, payload loginRequest) (*AuthResult, error) {
+	body, err := c.doRequest(ctx, "POST", "/login", payload, nil)
 	if err != nil {
+		if mfaErr := mfaRequired(nil, err); mfaErr != nil {
+			return nil, mfaErr
+		}
 		return nil, err
 	}
 
+	result, err := c.sessionFromLoginResponse(body)
+	if err != nil || !c.userOnSignIn {
+		return result, err
+	}
+
+	// The session is already stored, so return it even if the profile fails.
+	user, err := c.GetUserContext(ctx, result.Session.AccessToken)
+	if err != nil {
+		return result, fmt.Errorf("signed in but failed to load user: %w", err)
+	}
+	result.User = user
+	return result, nil
+}
+
+// sessionFromLoginResponse parses a login-style token response and stores the
+// session, or returns *MFARequiredError if a second factor is still needed.
+func (c *AuthClient) sessionFromLoginResponse(body []byte) (*AuthResult, error) {
 	var resp loginResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return nil, fmt.Errorf("failed to parse login response: %w", err)
 	}
+	if mfaErr := mfaRequired(&resp, nil); mfaErr != nil {
+		return nil, mfaErr
+	}
 
 	session := AuthSession{
 		AccessToken:  resp.AccessToken,
@@ -195,7 +546,58 @@ func (c *AuthClient) SignIn(email, password string) (*AuthResult, error) {
 		TokenType:    resp.TokenType,
 		ExpiresIn:    resp.ExpiresIn,
 	}
-	c.persistSession(session)
+	if err := c.persistSession(&session, AuthEventSignedIn); err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User:    nil,
+		Session: session,
+	}, nil
+}
+
+// RefreshSession exchanges the stored refresh token for a new session.
+// If the server rotates the refresh token the new one is stored; otherwise
+// the existing refresh token is kept.
+func (c *AuthClient) RefreshSession() (*AuthResult, error) {
+	return c.RefreshSessionContext(context.Background())
+}
+
+// RefreshSessionContext is like RefreshSession but uses ctx for cancellation and deadlines.
+func (c *AuthClient) RefreshSessionContext(ctx context.Context) (*AuthResult, error) {
+	c.mu.RLock()
+	refreshToken := c.refreshToken
+	c.mu.RUnlock()
+	if refreshToken == "" {
+		return nil, &WOWSQLError{Message: "refresh token is required to refresh the session"}
+	}
+
+	payload := map[string]interface{}{
+		"refresh_token": refreshToken,
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/refresh", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp loginResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse refresh response: %w", err)
+	}
+
+	session := AuthSession{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		TokenType:    resp.TokenType,
+		ExpiresIn:    resp.ExpiresIn,
+	}
+	if session.RefreshToken == "" {
+		session.RefreshToken = refreshToken
+	}
+	if err := c.persistSession(&session, AuthEventTokenRefreshed); err != nil {
+		return nil, err
+	}
 
 	return &AuthResult{
 		User:    nil,
@@ -203,21 +605,84 @@ func (c *AuthClient) SignIn(email, password string) (*AuthResult, error) {
 	}, nil
 }
 
+// EnsureValidToken refreshes the session if the stored access token expires
+// within the configured RefreshSkew. It is a no-op when the expiry is unknown
+// or no refresh token is stored.
+func (c *AuthClient) EnsureValidToken() error {
+	return c.EnsureValidTokenContext(context.Background())
+}
+
+// EnsureValidTokenContext is like EnsureValidToken but uses ctx for cancellation and deadlines.
+func (c *AuthClient) EnsureValidTokenContext(ctx context.Context) error {
+	c.mu.RLock()
+	expiresAt, refreshToken := c.expiresAt, c.refreshToken
+	c.mu.RUnlock()
+
+	if expiresAt.IsZero() || refreshToken == "" {
+		return nil
+	}
+	if time.Now().Add(c.refreshSkew).Before(expiresAt) {
+		return nil
+	}
+
+	_, err := c.RefreshSessionContext(ctx)
+	return err
+}
+
+// Ping checks that the project is reachable and the API key is accepted by
+// fetching the project's token signing keys. The error says whether the key
+// was rejected or the host is unreachable.
+func (c *AuthClient) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses ctx for cancellation and deadlines.
+func (c *AuthClient) PingContext(ctx context.Context) error {
+	_, err := c.doRequest(ctx, "GET", "/.well-known/jwks.json", nil, nil)
+	return pingError(err)
+}
+
 // GetUser fetches the current user profile using the stored access token.
+// When AutoRefresh is enabled the session is refreshed first if needed.
+// With WithUserCacheTTL a recently fetched profile is returned from memory.
 func (c *AuthClient) GetUser(tokenOverride ...string) (*AuthUser, error) {
+	return c.GetUserContext(context.Background(), tokenOverride...)
+}
+
+// GetUserContext is like GetUser but uses ctx for cancellation and deadlines.
+func (c *AuthClient) GetUserContext(ctx context.Context, tokenOverride ...string) (*AuthUser, error) {
+	return c.getUser(ctx, true, tokenOverride...)
+}
+
+func (c *AuthClient) getUser(ctx context.Context, useCache bool, tokenOverride ...string) (*AuthUser, error) {
+	hasOverride := len(tokenOverride) > 0 && tokenOverride[0] != ""
+	if c.autoRefresh && !hasOverride {
+		if err := c.EnsureValidTokenContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.RLock()
 	token := c.accessToken
-	if len(tokenOverride) > 0 && tokenOverride[0] != "" {
+	c.mu.RUnlock()
+	if hasOverride {
 		token = tokenOverride[0]
 	}
 	if token == "" {
 		return nil, &WOWSQLError{Message: "access token is required to fetch user profile"}
 	}
 
+	if useCache {
+		if user, ok := c.userCache.get(token); ok {
+			return user, nil
+		}
+	}
+
 	headers := map[string]string{
 		"Authorization": "Bearer " + token,
 	}
 
-	body, err := c.doRequest("GET", "/me", nil, headers)
+	body, err := c.doRequest(ctx, "GET", "/me", nil, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -226,14 +691,55 @@ func (c *AuthClient) GetUser(tokenOverride ...string) (*AuthUser, error) {
 	if err := json.Unmarshal(body, &user); err != nil {
 		return nil, fmt.Errorf("failed to parse user response: %w", err)
 	}
+	c.userCache.put(token, user)
 
 	return &user, nil
 }
 
-// GetOAuthAuthorizationURL requests the provider authorization URL.
+// GetOAuthAuthorizationURL requests the provider authorization URL. A random
+// state is added for CSRF protection and returned in State. The flow is saved
+// under it, in the session store if it implements OAuthStateStore and in
+// memory otherwise, for ExchangeOAuthCallbackWithState to check.
 func (c *AuthClient) GetOAuthAuthorizationURL(provider, redirectURL string) (*OAuthAuthorizeResponse, error) {
-	path := fmt.Sprintf("/oauth/%s?frontend_redirect_uri=%s", provider, url.QueryEscape(redirectURL))
-	body, err := c.doRequest("GET", path, nil, nil)
+	return c.GetOAuthAuthorizationURLContext(context.Background(), provider, redirectURL)
+}
+
+// GetOAuthAuthorizationURLContext is like GetOAuthAuthorizationURL but uses ctx for cancellation and deadlines.
+func (c *AuthClient) GetOAuthAuthorizationURLContext(ctx context.Context, provider, redirectURL string) (*OAuthAuthorizeResponse, error) {
+	query := url.Values{}
+	query.Set("frontend_redirect_uri", redirectURL)
+	return c.getOAuthAuthorizationURL(ctx, provider, query)
+}
+
+// GetOAuthAuthorizationURLWithPKCE requests the provider authorization URL
+// for a PKCE flow. codeChallenge is the S256 challenge returned by
+// GeneratePKCEChallenge; keep the matching verifier for ExchangeOAuthCallbackWithPKCE.
+func (c *AuthClient) GetOAuthAuthorizationURLWithPKCE(provider, redirectURL, codeChallenge string) (*OAuthAuthorizeResponse, error) {
+	return c.GetOAuthAuthorizationURLWithPKCEContext(context.Background(), provider, redirectURL, codeChallenge)
+}
+
+// GetOAuthAuthorizationURLWithPKCEContext is like GetOAuthAuthorizationURLWithPKCE but uses ctx for cancellation and deadlines.
+func (c *AuthClient) GetOAuthAuthorizationURLWithPKCEContext(ctx context.Context, provider, redirectURL, codeChallenge string) (*OAuthAuthorizeResponse, error) {
+	if codeChallenge == "" {
+		return nil, fmt.Errorf("codeChallenge is required for PKCE")
+	}
+
+	query := url.Values{}
+	query.Set("frontend_redirect_uri", redirectURL)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	return c.getOAuthAuthorizationURL(ctx, provider, query)
+}
+
+func (c *AuthClient) getOAuthAuthorizationURL(ctx context.Context, provider string, query url.Values) (*OAuthAuthorizeResponse, error) {
+	state, err := generateOAuthState()
+	if err != nil {
+		return nil, err
+	}
+	query.Set("state", state)
+
+	path := fmt.Sprintf("/oauth/%s?%s", provider, query.Encode())
+	body, err := c.doRequest(ctx, "GET", path, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +748,16 @@ func (c *AuthClient) GetOAuthAuthorizationURL(provider, redirectURL string) (*OA
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return nil, fmt.Errorf("failed to parse oauth response: %w", err)
 	}
+	resp.State = state
+
+	flowState := OAuthFlowState{
+		Provider:    provider,
+		RedirectURI: query.Get("frontend_redirect_uri"),
+		CreatedAt:   time.Now(),
+	}
+	if err := c.oauthStateStore().SaveOAuthState(state, flowState); err != nil {
+		return nil, fmt.Errorf("failed to save OAuth state: %w", err)
+	}
 
 	return &resp, nil
 }
@@ -249,7 +765,14 @@ func (c *AuthClient) GetOAuthAuthorizationURL(provider, redirectURL string) (*OA
 // ExchangeOAuthCallback exchanges OAuth callback code for access tokens.
 // After the user authorizes with the OAuth provider, the provider redirects
 // back with a code. Call this method to exchange that code for JWT tokens.
+// It does not check the callback state; web apps using the redirect flow
+// should use ExchangeOAuthCallbackWithState.
 func (c *AuthClient) ExchangeOAuthCallback(provider, code string, redirectURI *string) (*AuthResult, error) {
+	return c.ExchangeOAuthCallbackContext(context.Background(), provider, code, redirectURI)
+}
+
+// ExchangeOAuthCallbackContext is like ExchangeOAuthCallback but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ExchangeOAuthCallbackContext(ctx context.Context, provider, code string, redirectURI *string) (*AuthResult, error) {
 	payload := map[string]interface{}{
 		"code": code,
 	}
@@ -257,8 +780,52 @@ func (c *AuthClient) ExchangeOAuthCallback(provider, code string, redirectURI *s
 		payload["redirect_uri"] = *redirectURI
 	}
 
-	body, err := c.doRequest("POST", fmt.Sprintf("/oauth/%s/callback", provider), payload, nil)
+	return c.exchangeOAuthCallback(ctx, provider, payload)
+}
+
+// ExchangeOAuthCallbackWithPKCE exchanges an OAuth callback code obtained
+// through a PKCE flow, sending the code verifier from GeneratePKCEChallenge.
+func (c *AuthClient) ExchangeOAuthCallbackWithPKCE(provider, code, codeVerifier string, redirectURI *string) (*AuthResult, error) {
+	return c.ExchangeOAuthCallbackWithPKCEContext(context.Background(), provider, code, codeVerifier, redirectURI)
+}
+
+// ExchangeOAuthCallbackWithPKCEContext is like ExchangeOAuthCallbackWithPKCE but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ExchangeOAuthCallbackWithPKCEContext(ctx context.Context, provider, code, codeVerifier string, redirectURI *string) (*AuthResult, error) {
+	if codeVerifier == "" {
+		return nil, fmt.Errorf("codeVerifier is required for PKCE")
+	}
+
+	payload := map[string]interface{}{
+		"code":          code,
+		"code_verifier": codeVerifier,
+	}
+	if redirectURI != nil {
+		payload["redirect_uri"] = *redirectURI
+	}
+
+	return c.exchangeOAuthCallback(ctx, provider, payload)
+}
+
+func (c *AuthClient) exchangeOAuthCallback(ctx context.Context, provider string, payload map[string]interface{}) (*AuthResult, error) {
+	if c.oauthCallbackTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.oauthCallbackTimeout)
+		defer cancel()
+	}
+
+	path := fmt.Sprintf("/oauth/%s/callback", provider)
+	body, err := c.doRequest(ctx, "POST", path, payload, nil)
+	// Gateways time out while the provider round-trip is slow; retry once
+	// unless the retry policy already covers POST requests.
+	if isGatewayError(err) && !(c.retry.enabled() && c.retry.allowsMethod("POST")) {
+		if sleepContext(ctx, oauthCallbackRetryDelay) == nil {
+			body, err = c.doRequest(ctx, "POST", path, payload, nil)
+		}
+	}
 	if err != nil {
+		if isOAuthCodeUsedError(err) {
+			return nil, fmt.Errorf("%s OAuth code was already exchanged: %w: %w", provider, ErrOAuthCodeUsed, err)
+		}
 		return nil, err
 	}
 
@@ -273,7 +840,9 @@ func (c *AuthClient) ExchangeOAuthCallback(provider, code string, redirectURI *s
 		TokenType:    resp.TokenType,
 		ExpiresIn:    resp.ExpiresIn,
 	}
-	c.persistSession(session)
+	if err := c.persistSession(&session, AuthEventSignedIn); err != nil {
+		return nil, err
+	}
 
 	return &AuthResult{
 		User:    resp.User,
@@ -281,15 +850,66 @@ func (c *AuthClient) ExchangeOAuthCallback(provider, code string, redirectURI *s
 	}, nil
 }
 
+// oauthCallbackRetryDelay is the pause before retrying a callback exchange
+// that failed with 502 or 504.
+const oauthCallbackRetryDelay = 500 * time.Millisecond
+
+// ErrOAuthCodeUsed is returned by ExchangeOAuthCallback and its variants when
+// the server has already exchanged the authorization code, e.g. because an
+// earlier attempt succeeded behind a gateway timeout. The user has to start
+// the OAuth flow again.
+var ErrOAuthCodeUsed = errors.New("OAuth code already used")
+
+// isGatewayError reports whether err is a 502 or 504 response.
+func isGatewayError(err error) bool {
+	var apiErr *WOWSQLError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == 502 || apiErr.StatusCode == 504)
+}
+
+// isOAuthCodeUsedError reports whether err says the authorization code was
+// already exchanged.
+func isOAuthCodeUsedError(err error) bool {
+	var apiErr *WOWSQLError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode < 400 || apiErr.StatusCode >= 500 {
+		return false
+	}
+	if apiErr.Code == "code_already_used" {
+		return true
+	}
+	message := strings.ToLower(apiErr.Message)
+	return strings.Contains(message, "already used") || strings.Contains(message, "already been used")
+}
+
+// GeneratePKCEChallenge creates a random PKCE code verifier and its S256
+// code challenge. It panics if the system random source fails.
+func GeneratePKCEChallenge() (verifier, challenge string) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate PKCE verifier: %v", err))
+	}
+
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge
+}
+
 // ForgotPassword requests a password reset email.
 // Sends a password reset email to the user if they exist.
 // Always returns success to prevent email enumeration.
+// When the server rate-limits the request the error is a *RateLimitError
+// whose RetryAfter says how long to wait before trying again.
 func (c *AuthClient) ForgotPassword(email string) (map[string]interface{}, error) {
+	return c.ForgotPasswordContext(context.Background(), email)
+}
+
+// ForgotPasswordContext is like ForgotPassword but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ForgotPasswordContext(ctx context.Context, email string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"email": email,
 	}
 
-	body, err := c.doRequest("POST", "/forgot-password", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/forgot-password", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -305,12 +925,17 @@ func (c *AuthClient) ForgotPassword(email string) (map[string]interface{}, error
 // ResetPassword resets password with token.
 // Validates the reset token and updates the user's password.
 func (c *AuthClient) ResetPassword(token, newPassword string) (map[string]interface{}, error) {
+	return c.ResetPasswordContext(context.Background(), token, newPassword)
+}
+
+// ResetPasswordContext is like ResetPassword but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ResetPasswordContext(ctx context.Context, token, newPassword string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"token":        token,
 		"new_password": newPassword,
 	}
 
-	body, err := c.doRequest("POST", "/reset-password", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/reset-password", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -323,40 +948,194 @@ func (c *AuthClient) ResetPassword(token, newPassword string) (map[string]interf
 	return result, nil
 }
 
-// GetSession returns the currently stored tokens.
+// GetSession returns the currently stored tokens with their expiry, which is
+// taken from the server's expires_in or, failing that, the token's exp claim.
 func (c *AuthClient) GetSession() AuthSession {
-	return AuthSession{
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	session := AuthSession{
 		AccessToken:  c.accessToken,
 		RefreshToken: c.refreshToken,
 		TokenType:    "bearer",
+		ExpiresAt:    c.expiresAt,
+	}
+	if !c.expiresAt.IsZero() {
+		if remaining := time.Until(c.expiresAt); remaining > 0 {
+			session.ExpiresIn = int(remaining / time.Second)
+		}
 	}
+	return session
 }
 
-// SetSession overrides stored tokens.
+// sessionExpiry returns when a token expires: expiresIn seconds from now if
+// the server reported it, else the token's exp claim, else the zero time.
+func sessionExpiry(accessToken string, expiresIn int) time.Time {
+	if expiresIn > 0 {
+		return time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	if claims, err := DecodeJWTClaims(accessToken); err == nil {
+		return claims.Expiry()
+	}
+	return time.Time{}
+}
+
+// SetSession overrides stored tokens. The session is also written to the
+// SessionStore if one is configured; persistence errors are ignored here.
 func (c *AuthClient) SetSession(accessToken, refreshToken string) {
+	session := AuthSession{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "bearer",
+		ExpiresAt:    sessionExpiry(accessToken, 0),
+	}
+
+	c.mu.Lock()
 	c.accessToken = accessToken
 	c.refreshToken = refreshToken
+	c.expiresAt = session.ExpiresAt
+	if c.sessionStore != nil {
+		_ = c.sessionStore.Save(session)
+	}
+	c.mu.Unlock()
+	c.userCache.clear()
+
+	c.notifyAuthStateChange(AuthEventSignedIn, session)
 }
 
-// ClearSession removes stored tokens.
+// ClearSession removes stored tokens, including any copy in the SessionStore.
 func (c *AuthClient) ClearSession() {
+	c.mu.Lock()
 	c.accessToken = ""
 	c.refreshToken = ""
+	c.expiresAt = time.Time{}
+	if c.sessionStore != nil {
+		_ = c.sessionStore.Save(AuthSession{})
+	}
+	c.mu.Unlock()
+	c.userCache.clear()
+
+	c.notifyAuthStateChange(AuthEventSignedOut, AuthSession{})
+}
+
+// SignOut revokes the current session on the server and clears stored tokens.
+// Local tokens are cleared even if the server call fails.
+func (c *AuthClient) SignOut() error {
+	return c.SignOutContext(context.Background())
+}
+
+// SignOutContext is like SignOut but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignOutContext(ctx context.Context) error {
+	defer c.ClearSession()
+
+	c.mu.RLock()
+	accessToken, refreshToken := c.accessToken, c.refreshToken
+	c.mu.RUnlock()
+
+	if accessToken == "" {
+		return nil
+	}
+
+	payload := map[string]interface{}{}
+	if refreshToken != "" {
+		payload["refresh_token"] = refreshToken
+	}
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+
+	_, err := c.doRequest(ctx, "POST", "/logout", payload, headers)
+	return err
+}
+
+// Purpose selects what an OTP or magic link is for. Untyped string constants
+// such as "login" are still accepted.
+type Purpose string
+
+// Purposes accepted by SendOTP and VerifyOTP.
+const (
+	OTPPurposeLogin         Purpose = "login"
+	OTPPurposeSignup        Purpose = "signup"
+	OTPPurposePasswordReset Purpose = "password_reset"
+)
+
+// Purposes accepted by SendMagicLink.
+const (
+	MagicLinkPurposeLogin             Purpose = "login"
+	MagicLinkPurposeSignup            Purpose = "signup"
+	MagicLinkPurposeEmailVerification Purpose = "email_verification"
+)
+
+// SendOption configures SendOTP and SendMagicLink.
+type SendOption func(*sendOptions)
+
+type sendOptions struct {
+	redirectTo string
+}
+
+// WithRedirectTo sets where the link in the email takes the user, e.g. a
+// tenant-specific page or a mobile deep link such as "myapp://login". It must
+// be an absolute URL.
+func WithRedirectTo(redirectURL string) SendOption {
+	return func(o *sendOptions) {
+		o.redirectTo = redirectURL
+	}
+}
+
+// apply validates the options and adds them to payload.
+func (o sendOptions) apply(payload map[string]interface{}) error {
+	if o.redirectTo == "" {
+		return nil
+	}
+	u, err := url.Parse(o.redirectTo)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URL %q: %w", o.redirectTo, err)
+	}
+	if u.Scheme == "" || ((u.Scheme == "http" || u.Scheme == "https") && u.Host == "") {
+		return fmt.Errorf("invalid redirect URL %q: must be absolute", o.redirectTo)
+	}
+	payload["redirect_to"] = o.redirectTo
+	return nil
+}
+
+func applySendOptions(opts []SendOption, payload map[string]interface{}) error {
+	var options sendOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options.apply(payload)
+}
+
+func validOTPPurpose(purpose Purpose) error {
+	switch purpose {
+	case OTPPurposeLogin, OTPPurposeSignup, OTPPurposePasswordReset:
+		return nil
+	}
+	return fmt.Errorf("purpose must be 'login', 'signup', or 'password_reset'")
 }
 
 // SendOTP sends an OTP code to user's email.
 // Supports login, signup, and password_reset purposes.
-func (c *AuthClient) SendOTP(email, purpose string) (map[string]interface{}, error) {
-	if purpose != "login" && purpose != "signup" && purpose != "password_reset" {
-		return nil, fmt.Errorf("purpose must be 'login', 'signup', or 'password_reset'")
+// When the server rate-limits the request the error is a *RateLimitError
+// whose RetryAfter says how long to wait before trying again.
+func (c *AuthClient) SendOTP(email string, purpose Purpose, opts ...SendOption) (map[string]interface{}, error) {
+	return c.SendOTPContext(context.Background(), email, purpose, opts...)
+}
+
+// SendOTPContext is like SendOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SendOTPContext(ctx context.Context, email string, purpose Purpose, opts ...SendOption) (map[string]interface{}, error) {
+	if err := validOTPPurpose(purpose); err != nil {
+		return nil, err
 	}
 
 	payload := map[string]interface{}{
 		"email":   email,
 		"purpose": purpose,
 	}
+	if err := applySendOptions(opts, payload); err != nil {
+		return nil, err
+	}
 
-	body, err := c.doRequest("POST", "/otp/send", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/otp/send", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -373,12 +1152,17 @@ func (c *AuthClient) SendOTP(email, purpose string) (map[string]interface{}, err
 // For signup: Creates new user if doesn't exist
 // For login: Authenticates existing user
 // For password_reset: Updates password if newPassword provided
-func (c *AuthClient) VerifyOTP(email, otp, purpose string, newPassword *string) (*AuthResult, error) {
-	if purpose != "login" && purpose != "signup" && purpose != "password_reset" {
-		return nil, fmt.Errorf("purpose must be 'login', 'signup', or 'password_reset'")
+func (c *AuthClient) VerifyOTP(email, otp string, purpose Purpose, newPassword *string) (*AuthResult, error) {
+	return c.VerifyOTPContext(context.Background(), email, otp, purpose, newPassword)
+}
+
+// VerifyOTPContext is like VerifyOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) VerifyOTPContext(ctx context.Context, email, otp string, purpose Purpose, newPassword *string) (*AuthResult, error) {
+	if err := validOTPPurpose(purpose); err != nil {
+		return nil, err
 	}
 
-	if purpose == "password_reset" && newPassword == nil {
+	if purpose == OTPPurposePasswordReset && newPassword == nil {
 		return nil, fmt.Errorf("newPassword is required for password_reset purpose")
 	}
 
@@ -391,12 +1175,12 @@ func (c *AuthClient) VerifyOTP(email, otp, purpose string, newPassword *string)
 		payload["new_password"] = *newPassword
 	}
 
-	body, err := c.doRequest("POST", "/otp/verify", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/otp/verify", payload, nil)
 	if err != nil {
-		return nil, err
+		return nil, parseOTPError(err)
 	}
 
-	if purpose == "password_reset" {
+	if purpose == OTPPurposePasswordReset {
 		var result map[string]interface{}
 		if err := json.Unmarshal(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse verify OTP response: %w", err)
@@ -418,7 +1202,9 @@ func (c *AuthClient) VerifyOTP(email, otp, purpose string, newPassword *string)
 		TokenType:    resp.TokenType,
 		ExpiresIn:    resp.ExpiresIn,
 	}
-	c.persistSession(session)
+	if err := c.persistSession(&session, AuthEventSignedIn); err != nil {
+		return nil, err
+	}
 
 	return &AuthResult{
 		User:    resp.User,
@@ -428,8 +1214,18 @@ func (c *AuthClient) VerifyOTP(email, otp, purpose string, newPassword *string)
 
 // SendMagicLink sends a magic link to user's email.
 // Supports login, signup, and email_verification purposes.
-func (c *AuthClient) SendMagicLink(email, purpose string) (map[string]interface{}, error) {
-	if purpose != "login" && purpose != "signup" && purpose != "email_verification" {
+// Use WithRedirectTo to choose where the link lands the user.
+// When the server rate-limits the request the error is a *RateLimitError
+// whose RetryAfter says how long to wait before trying again.
+func (c *AuthClient) SendMagicLink(email string, purpose Purpose, opts ...SendOption) (map[string]interface{}, error) {
+	return c.SendMagicLinkContext(context.Background(), email, purpose, opts...)
+}
+
+// SendMagicLinkContext is like SendMagicLink but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SendMagicLinkContext(ctx context.Context, email string, purpose Purpose, opts ...SendOption) (map[string]interface{}, error) {
+	switch purpose {
+	case MagicLinkPurposeLogin, MagicLinkPurposeSignup, MagicLinkPurposeEmailVerification:
+	default:
 		return nil, fmt.Errorf("purpose must be 'login', 'signup', or 'email_verification'")
 	}
 
@@ -437,8 +1233,11 @@ func (c *AuthClient) SendMagicLink(email, purpose string) (map[string]interface{
 		"email":   email,
 		"purpose": purpose,
 	}
+	if err := applySendOptions(opts, payload); err != nil {
+		return nil, err
+	}
 
-	body, err := c.doRequest("POST", "/magic-link/send", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/magic-link/send", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -453,11 +1252,16 @@ func (c *AuthClient) SendMagicLink(email, purpose string) (map[string]interface{
 
 // VerifyEmail verifies email using token (from magic link or OTP verification).
 func (c *AuthClient) VerifyEmail(token string) (map[string]interface{}, error) {
+	return c.VerifyEmailContext(context.Background(), token)
+}
+
+// VerifyEmailContext is like VerifyEmail but uses ctx for cancellation and deadlines.
+func (c *AuthClient) VerifyEmailContext(ctx context.Context, token string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"token": token,
 	}
 
-	body, err := c.doRequest("POST", "/verify-email", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/verify-email", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -472,12 +1276,19 @@ func (c *AuthClient) VerifyEmail(token string) (map[string]interface{}, error) {
 
 // ResendVerification resends verification email.
 // Always returns success to prevent email enumeration.
+// When the server rate-limits the request the error is a *RateLimitError
+// whose RetryAfter says how long to wait before trying again.
 func (c *AuthClient) ResendVerification(email string) (map[string]interface{}, error) {
+	return c.ResendVerificationContext(context.Background(), email)
+}
+
+// ResendVerificationContext is like ResendVerification but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ResendVerificationContext(ctx context.Context, email string) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"email": email,
 	}
 
-	body, err := c.doRequest("POST", "/resend-verification", payload, nil)
+	body, err := c.doRequest(ctx, "POST", "/resend-verification", payload, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -490,71 +1301,183 @@ func (c *AuthClient) ResendVerification(email string) (map[string]interface{}, e
 	return result, nil
 }
 
-func (c *AuthClient) persistSession(session AuthSession) {
+// bearerHeaders returns headers authenticating as the signed-in user.
+func (c *AuthClient) bearerHeaders(action string) (map[string]string, error) {
+	c.mu.RLock()
+	token := c.accessToken
+	c.mu.RUnlock()
+	if token == "" {
+		return nil, &WOWSQLError{Message: "access token is required to " + action}
+	}
+	return map[string]string{
+		"Authorization": "Bearer " + token,
+	}, nil
+}
+
+// sessionFromAuthResponse parses a token-issuing response, stores the session
+// and returns it alongside the user (if the server included one).
+func (c *AuthClient) sessionFromAuthResponse(body []byte, operation string) (*AuthResult, error) {
+	var resp authResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", operation, err)
+	}
+
+	session := AuthSession{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		TokenType:    resp.TokenType,
+		ExpiresIn:    resp.ExpiresIn,
+	}
+	if err := c.persistSession(&session, AuthEventSignedIn); err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User:    resp.User,
+		Session: session,
+	}, nil
+}
+
+func (c *AuthClient) persistSession(session *AuthSession, event string) error {
+	if session.ExpiresAt.IsZero() {
+		session.ExpiresAt = sessionExpiry(session.AccessToken, session.ExpiresIn)
+	}
+
+	c.mu.Lock()
 	c.accessToken = session.AccessToken
 	c.refreshToken = session.RefreshToken
+	c.expiresAt = session.ExpiresAt
+	var saveErr error
+	if c.sessionStore != nil {
+		saveErr = c.sessionStore.Save(*session)
+	}
+	c.mu.Unlock()
+	c.userCache.clear()
+
+	c.notifyAuthStateChange(event, *session)
+
+	if saveErr != nil {
+		return fmt.Errorf("failed to save session: %w", saveErr)
+	}
+	return nil
 }
 
-func (c *AuthClient) doRequest(method, path string, body interface{}, headers map[string]string) ([]byte, error) {
-	var reader io.Reader
+func (c *AuthClient) doRequest(ctx context.Context, method, path string, body interface{}, headers map[string]string) ([]byte, error) {
+	respBody, _, err := c.doRequestWithHeaders(ctx, method, path, body, headers)
+	return respBody, err
+}
+
+// doRequestWithHeaders is like doRequest but also returns the response headers.
+func (c *AuthClient) doRequestWithHeaders(ctx context.Context, method, path string, body interface{}, headers map[string]string) ([]byte, http.Header, error) {
+	var payload []byte
 	if body != nil {
-		payload, err := json.Marshal(body)
+		encoded, err := json.Marshal(body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encode request body: %w", err)
+			return nil, nil, fmt.Errorf("failed to encode request body: %w", err)
 		}
+		payload = encoded
+	}
+
+	// Requests the caller marked as safe to repeat are retried whatever the method.
+	attempts := 1
+	if c.retry.enabled() && (c.retry.allowsMethod(method) || isRetrySafe(ctx)) {
+		attempts = c.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		bodyBytes, respHeader, retryAfter, err := c.doRequestOnce(ctx, method, path, payload, headers)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !isRetryableError(err) {
+			return bodyBytes, respHeader, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// doRequestOnce performs a single attempt and reports the response headers
+// and any Retry-After hint.
+func (c *AuthClient) doRequestOnce(ctx context.Context, method, path string, payload []byte, headers map[string]string) ([]byte, http.Header, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
 		reader = bytes.NewReader(payload)
 	}
 
 	url := c.baseURL + path
-	req, err := http.NewRequest(method, url, reader)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	c.mu.RLock()
+	apiKey, publicKey := c.apiKey, c.publicKey
+	c.mu.RUnlock()
+
 	req.Header.Set("Content-Type", "application/json")
 	// UNIFIED AUTHENTICATION: Use Authorization header (same as database operations)
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	} else if c.publicKey != "" {
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	} else if publicKey != "" {
 		// Backward compatibility
-		req.Header.Set("Authorization", "Bearer "+c.publicKey)
+		req.Header.Set("Authorization", "Bearer "+publicKey)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
 	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	if err := intercept(c.interceptors, req); err != nil {
+		return nil, nil, 0, err
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClientFor(ctx, c.httpClient).Do(req)
 	if err != nil {
-		return nil, &NetworkError{Err: err}
+		return nil, nil, 0, &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, parseError(resp.StatusCode, bodyBytes)
+		return nil, resp.Header, parseRetryAfter(resp.Header.Get("Retry-After")), withRetryAfter(parseError(resp.StatusCode, bodyBytes), resp.Header)
 	}
 
-	return bodyBytes, nil
+	return bodyBytes, resp.Header, 0, nil
 }
 
 func buildAuthBaseURL(projectURL, baseDomain string, secure bool) string {
+	return ResolveProjectURL(projectURL, baseDomain, secure) + DefaultAuthBasePath
+}
+
+// ResolveProjectURL turns a project slug ("myproject"), host
+// ("myproject.wowsql.com") or full URL into the project's base URL, the same
+// way AuthConfig resolves ProjectURL. baseDomain defaults to "wowsql.com" and
+// secure selects https for slugs and hosts. Full URLs are kept as they are.
+// Trailing "/" and "/api" are removed from every form.
+func ResolveProjectURL(projectURL, baseDomain string, secure bool) string {
 	if baseDomain == "" {
 		baseDomain = "wowsql.com"
 	}
 
-	normalized := strings.TrimSpace(projectURL)
+	// Trim first so "myproject/" is still recognized as a slug
+	normalized := strings.TrimRight(strings.TrimSpace(projectURL), "/")
+	normalized = strings.TrimRight(strings.TrimSuffix(normalized, "/api"), "/")
 	
 	// If it's already a full URL, use it as-is
 	if strings.HasPrefix(normalized, "http://") || strings.HasPrefix(normalized, "https://") {
-		normalized = strings.TrimSuffix(normalized, "/")
-		if strings.HasSuffix(normalized, "/api") {
-			normalized = strings.TrimSuffix(normalized, "/api")
-		}
-		return normalized + "/api/auth"
+		return normalized
 	}
 
 	// If it already contains the base domain, don't append it again
@@ -573,10 +1496,5 @@ func buildAuthBaseURL(projectURL, baseDomain string, secure bool) string {
 		normalized = fmt.Sprintf("%s://%s.%s", protocol, normalized, baseDomain)
 	}
 
-	normalized = strings.TrimSuffix(normalized, "/")
-	if strings.HasSuffix(normalized, "/api") {
-		normalized = strings.TrimSuffix(normalized, "/api")
-	}
-
-	return normalized + "/api/auth"
+	return normalized
 }
@@ -0,0 +1,133 @@
+package WOWSQL
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAuthClientConcurrentSessionAccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access-login","refresh_token":"refresh-login","token_type":"bearer","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				switch (i + j) % 4 {
+				case 0:
+					id := fmt.Sprintf("%d-%d", i, j)
+					client.SetSession("access-"+id, "refresh-"+id)
+				case 1:
+					client.ClearSession()
+				case 2:
+					if _, err := client.SignIn("user@example.com", "password"); err != nil {
+						t.Errorf("SignIn: %v", err)
+					}
+				case 3:
+					session := client.GetSession()
+					access := strings.TrimPrefix(session.AccessToken, "access-")
+					refresh := strings.TrimPrefix(session.RefreshToken, "refresh-")
+					if access != refresh {
+						t.Errorf("GetSession returned a torn session: %q / %q", session.AccessToken, session.RefreshToken)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestResolveProjectURL(t *testing.T) {
+	tests := []struct {
+		projectURL string
+		baseDomain string
+		secure     bool
+		want       string
+	}{
+		{"myproject", "", true, "https://myproject.wowsql.com"},
+		{"myproject", "", false, "http://myproject.wowsql.com"},
+		{" myproject/ ", "", true, "https://myproject.wowsql.com"},
+		{"myproject", "example.dev", true, "https://myproject.example.dev"},
+		{"myproject.wowsql.com", "", true, "https://myproject.wowsql.com"},
+		{"myproject.wowsql.com/api/", "", true, "https://myproject.wowsql.com"},
+		{"https://myproject.wowsql.com", "", false, "https://myproject.wowsql.com"},
+		{"https://myproject.wowsql.com/", "", true, "https://myproject.wowsql.com"},
+		{"https://myproject.wowsql.com/api", "", true, "https://myproject.wowsql.com"},
+		{"http://localhost:8000/api/", "", true, "http://localhost:8000"},
+	}
+	for _, tt := range tests {
+		if got := ResolveProjectURL(tt.projectURL, tt.baseDomain, tt.secure); got != tt.want {
+			t.Errorf("ResolveProjectURL(%q, %q, %v) = %q, want %q", tt.projectURL, tt.baseDomain, tt.secure, got, tt.want)
+		}
+	}
+}
+
+func TestAuthClientSetBasePath(t *testing.T) {
+	client := NewAuthClient(AuthConfig{ProjectURL: "https://myproject.wowsql.com", APIKey: "wowsql_anon_test"})
+	tests := []struct {
+		basePath string
+		want     string
+	}{
+		{"auth/v2/", "https://myproject.wowsql.com/auth/v2"},
+		{"", "https://myproject.wowsql.com" + DefaultAuthBasePath},
+		{"/custom", "https://myproject.wowsql.com/custom"},
+		{"/", "https://myproject.wowsql.com" + DefaultAuthBasePath},
+	}
+	for _, tt := range tests {
+		client.SetBasePath(tt.basePath)
+		if got := client.baseURL; got != tt.want {
+			t.Errorf("SetBasePath(%q): base URL %q, want %q", tt.basePath, got, tt.want)
+		}
+	}
+}
+
+func TestAuthClientPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{"ok", http.StatusOK, ""},
+		{"rejected key", http.StatusUnauthorized, "API key was rejected"},
+		{"server error", http.StatusInternalServerError, "ping failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != DefaultAuthBasePath+"/.well-known/jwks.json" {
+					t.Errorf("pinged %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `{"keys":[]}`)
+			}))
+			defer server.Close()
+
+			err := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"}).Ping()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Ping: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Ping = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	err := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"}).Ping()
+	if err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Ping of a closed server = %v, want an unreachable error", err)
+	}
+}
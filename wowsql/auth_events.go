@@ -0,0 +1,48 @@
+package WOWSQL
+
+// Auth state change events passed to OnAuthStateChange listeners.
+const (
+	AuthEventSignedIn       = "SIGNED_IN"
+	AuthEventSignedOut      = "SIGNED_OUT"
+	AuthEventTokenRefreshed = "TOKEN_REFRESHED"
+)
+
+type authStateListener struct {
+	id int
+	fn func(event string, session AuthSession)
+}
+
+// OnAuthStateChange registers a listener that is called whenever the stored
+// session changes (sign-in, sign-out or token refresh). Listeners run
+// synchronously on the goroutine that changed the session, so they should
+// return quickly. The returned function unregisters the listener.
+func (c *AuthClient) OnAuthStateChange(listener func(event string, session AuthSession)) (unsubscribe func()) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	c.nextListenerID++
+	id := c.nextListenerID
+	c.listeners = append(c.listeners, authStateListener{id: id, fn: listener})
+
+	return func() {
+		c.listenersMu.Lock()
+		defer c.listenersMu.Unlock()
+		for i, l := range c.listeners {
+			if l.id == id {
+				c.listeners = append(c.listeners[:i:i], c.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (c *AuthClient) notifyAuthStateChange(event string, session AuthSession) {
+	c.listenersMu.Lock()
+	listeners := make([]authStateListener, len(c.listeners))
+	copy(listeners, c.listeners)
+	c.listenersMu.Unlock()
+
+	for _, l := range listeners {
+		l.fn(event, session)
+	}
+}
@@ -0,0 +1,52 @@
+package WOWSQL
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Providers accepted by SignInWithIDToken.
+const (
+	IDTokenProviderGoogle = "google"
+	IDTokenProviderApple  = "apple"
+)
+
+var idTokenProviders = map[string]bool{
+	IDTokenProviderGoogle: true,
+	IDTokenProviderApple:  true,
+}
+
+// SignInWithIDToken exchanges an ID token issued by a native Google or Apple
+// SDK for a session and stores it. Use it in apps that sign in without a
+// browser redirect; web apps should use GetOAuthAuthorizationURL instead.
+//
+// nonce is the raw nonce passed to the provider when the ID token was
+// requested, if any.
+func (c *AuthClient) SignInWithIDToken(provider, idToken string, nonce *string) (*AuthResult, error) {
+	return c.SignInWithIDTokenContext(context.Background(), provider, idToken, nonce)
+}
+
+// SignInWithIDTokenContext is like SignInWithIDToken but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignInWithIDTokenContext(ctx context.Context, provider, idToken string, nonce *string) (*AuthResult, error) {
+	if !idTokenProviders[provider] {
+		return nil, fmt.Errorf("unsupported ID token provider %q", provider)
+	}
+	if idToken == "" {
+		return nil, fmt.Errorf("idToken is required")
+	}
+
+	payload := map[string]interface{}{
+		"id_token": idToken,
+	}
+	if nonce != nil {
+		payload["nonce"] = *nonce
+	}
+
+	body, err := c.doRequest(ctx, "POST", fmt.Sprintf("/oauth/%s/id-token", url.PathEscape(provider)), payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sessionFromAuthResponse(body, "ID token sign-in")
+}
@@ -0,0 +1,67 @@
+package WOWSQL
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Identity is an OAuth provider account linked to a user.
+type Identity struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	LinkedAt string `json:"linked_at"`
+}
+
+// GetIdentities lists the OAuth identities linked to the signed-in user.
+func (c *AuthClient) GetIdentities() ([]Identity, error) {
+	return c.GetIdentitiesContext(context.Background())
+}
+
+// GetIdentitiesContext is like GetIdentities but uses ctx for cancellation and deadlines.
+func (c *AuthClient) GetIdentitiesContext(ctx context.Context) ([]Identity, error) {
+	headers, err := c.bearerHeaders("list identities")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(ctx, "GET", "/identities", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Identities []Identity `json:"identities"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse identities response: %w", err)
+	}
+
+	return resp.Identities, nil
+}
+
+// UnlinkIdentity disconnects a linked OAuth identity from the signed-in user.
+// The server refuses to unlink a user's only sign-in method.
+func (c *AuthClient) UnlinkIdentity(identityID string) error {
+	return c.UnlinkIdentityContext(context.Background(), identityID)
+}
+
+// UnlinkIdentityContext is like UnlinkIdentity but uses ctx for cancellation and deadlines.
+func (c *AuthClient) UnlinkIdentityContext(ctx context.Context, identityID string) error {
+	if identityID == "" {
+		return fmt.Errorf("identityID is required")
+	}
+
+	headers, err := c.bearerHeaders("unlink identity")
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.doRequest(ctx, "DELETE", "/identities/"+url.PathEscape(identityID), nil, headers); err != nil {
+		return err
+	}
+
+	c.InvalidateUserCache()
+	return nil
+}
@@ -0,0 +1,337 @@
+package WOWSQL
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSCacheTTL is how long VerifyToken reuses the project's signing
+// keys before fetching them again.
+const DefaultJWKSCacheTTL = 10 * time.Minute
+
+// jwksMinRefresh limits how often a token signed with an unknown key can
+// trigger a refetch, so forged key IDs cannot hammer the auth service.
+const jwksMinRefresh = 30 * time.Second
+
+// tokenLeeway is the clock skew tolerated when checking exp and nbf.
+const tokenLeeway = 30 * time.Second
+
+var (
+	// ErrInvalidToken is returned by VerifyToken for tokens that are
+	// malformed, have a bad signature or fail a claim check.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned by VerifyToken for expired tokens. Such
+	// errors also match ErrInvalidToken.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// jwksCache holds the project's public signing keys by key ID.
+type jwksCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// VerifyOption configures VerifyToken.
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	audience string
+	issuer   string
+}
+
+// WithExpectedAudience makes VerifyToken reject tokens whose aud claim does
+// not contain audience.
+func WithExpectedAudience(audience string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.audience = audience
+	}
+}
+
+// WithExpectedIssuer makes VerifyToken reject tokens whose iss claim is not
+// issuer.
+func WithExpectedIssuer(issuer string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithJWKSCacheTTL sets how long VerifyToken caches the project's signing
+// keys. Zero means DefaultJWKSCacheTTL. Call it before the client is shared
+// between goroutines.
+func (c *AuthClient) WithJWKSCacheTTL(ttl time.Duration) *AuthClient {
+	c.jwks.mu.Lock()
+	c.jwks.ttl = ttl
+	c.jwks.mu.Unlock()
+	return c
+}
+
+// VerifyToken validates an access token issued by the project and returns
+// its claims. It is meant for backends that receive tokens from clients.
+//
+// The signature is checked against the project's JSON Web Key Set, fetched
+// from /.well-known/jwks.json and cached (see WithJWKSCacheTTL). A token
+// signed with a key that is not cached triggers a refetch, so key rotation
+// is picked up without waiting for the cache to expire. The exp and nbf
+// claims are always checked, allowing 30 seconds of clock skew; aud and iss
+// are checked when WithExpectedAudience or WithExpectedIssuer is given.
+//
+// Failures match ErrInvalidToken, and expired tokens also match
+// ErrTokenExpired.
+func (c *AuthClient) VerifyToken(token string, opts ...VerifyOption) (*Claims, error) {
+	return c.VerifyTokenContext(context.Background(), token, opts...)
+}
+
+// VerifyTokenContext is like VerifyToken but uses ctx for cancellation and deadlines.
+func (c *AuthClient) VerifyTokenContext(ctx context.Context, token string, opts ...VerifyOption) (*Claims, error) {
+	var options verifyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 segments, got %d", ErrInvalidToken, len(segments))
+	}
+
+	rawHeader, err := decodeSegment(segments[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %v", ErrInvalidToken, err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := decodeSegment(segments[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature: %v", ErrInvalidToken, err)
+	}
+
+	key, err := c.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, segments[0]+"."+segments[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims, err := DecodeJWTClaims(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := checkClaims(claims, options, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// signingKey returns the cached key for kid, fetching the key set when the
+// cache is stale or does not know kid. A token without kid is accepted only
+// when the set holds a single key.
+func (c *AuthClient) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.jwks.mu.Lock()
+	defer c.jwks.mu.Unlock()
+
+	ttl := c.jwks.ttl
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	age := time.Since(c.jwks.fetchedAt)
+
+	key, ok := c.jwks.lookup(kid)
+	if (ok && age < ttl) || (!ok && c.jwks.keys != nil && age < jwksMinRefresh) {
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+		}
+		return key, nil
+	}
+
+	keys, err := c.fetchJWKS(ctx)
+	if err != nil {
+		// Keep verifying with the previous keys if the refresh fails.
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	c.jwks.keys = keys
+	c.jwks.fetchedAt = time.Now()
+
+	if key, ok := c.jwks.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+func (jc *jwksCache) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" {
+		if len(jc.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range jc.keys {
+			return key, true
+		}
+	}
+	key, ok := jc.keys[kid]
+	return key, ok
+}
+
+func (c *AuthClient) fetchJWKS(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	body, err := c.doRequest(ctx, "GET", "/.well-known/jwks.json", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip key types this client does not understand.
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(segment string) (*big.Int, error) {
+	raw, err := decodeSegment(segment)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// verifySignature checks a JWS signature. Only asymmetric algorithms are
+// accepted; "none" and HMAC tokens are rejected.
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] == 'R' && rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if alg[0] == 'E' && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(pub, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+}
+
+func checkClaims(claims *Claims, options verifyOptions, now time.Time) error {
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(claims.Expiry().Add(tokenLeeway)) {
+		return fmt.Errorf("%w: %w at %s", ErrInvalidToken, ErrTokenExpired, claims.Expiry().Format(time.RFC3339))
+	}
+	if claims.NotBefore != 0 && now.Add(tokenLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if options.issuer != "" && claims.Issuer != options.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	if options.audience != "" && !claims.HasAudience(options.audience) {
+		return fmt.Errorf("%w: token not issued for audience %q", ErrInvalidToken, options.audience)
+	}
+	return nil
+}
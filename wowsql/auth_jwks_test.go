@@ -0,0 +1,224 @@
+package WOWSQL
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer serves a JSON Web Key Set that tests can rotate and counts how
+// often it is fetched.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []jsonWebKey
+	fetches int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+}
+
+func (s *jwksServer) setKeys(keys ...jsonWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// signTestToken builds a JWT with the given header values and claims, signed
+// with key: an *rsa.PrivateKey (RS256), an *ecdsa.PrivateKey (ES256), a
+// []byte secret (HS256) or nil for an unsigned token.
+func signTestToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &jwksServer{}
+	backend.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+	server := httptest.NewServer(backend)
+	defer server.Close()
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"})
+
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "user-1",
+			"iss": "https://myproject.wowsql.com",
+			"aud": "authenticated",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		opts    []VerifyOption
+		wantErr error
+	}{
+		{"valid RS256", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(nil)), nil, nil},
+		{"valid ES256", signTestToken(t, "ES256", "ec-1", ecKey, claims(nil)), nil, nil},
+		{"expected audience and issuer", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(nil)),
+			[]VerifyOption{WithExpectedAudience("authenticated"), WithExpectedIssuer("https://myproject.wowsql.com")}, nil},
+		{"wrong signature", signTestToken(t, "RS256", "rsa-1", otherRSAKey, claims(nil)), nil, ErrInvalidToken},
+		{"RS256 token for an EC key", signTestToken(t, "RS256", "ec-1", rsaKey, claims(nil)), nil, ErrInvalidToken},
+		{"alg none", signTestToken(t, "none", "rsa-1", nil, claims(nil)), nil, ErrInvalidToken},
+		{"HS256 with the public key as secret", signTestToken(t, "HS256", "rsa-1", rsaKey.N.Bytes(), claims(nil)), nil, ErrInvalidToken},
+		{"expired", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), nil, ErrTokenExpired},
+		{"expired within leeway", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()})), nil, nil},
+		{"not valid yet", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})), nil, ErrInvalidToken},
+		{"missing exp", signTestToken(t, "RS256", "rsa-1", rsaKey, map[string]interface{}{"sub": "user-1"}), nil, ErrInvalidToken},
+		{"audience mismatch", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(nil)), []VerifyOption{WithExpectedAudience("admin")}, ErrInvalidToken},
+		{"issuer mismatch", signTestToken(t, "RS256", "rsa-1", rsaKey, claims(nil)), []VerifyOption{WithExpectedIssuer("https://other.wowsql.com")}, ErrInvalidToken},
+		{"malformed", "not-a-token", nil, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.VerifyToken(tt.token, tt.opts...)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifyToken: %v", err)
+				}
+				if got.Subject != "user-1" {
+					t.Errorf("subject %q, want user-1", got.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyToken = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(tt.wantErr, ErrTokenExpired) && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expired token error %v does not match ErrInvalidToken", err)
+			}
+		})
+	}
+	if n := backend.fetchCount(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1 while cached", n)
+	}
+}
+
+func TestVerifyTokenRefetchesUnknownKey(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &jwksServer{}
+	backend.setKeys(rsaJWK("old", oldKey))
+	server := httptest.NewServer(backend)
+	defer server.Close()
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"})
+
+	claims := map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	if _, err := client.VerifyToken(signTestToken(t, "RS256", "old", oldKey, claims)); err != nil {
+		t.Fatalf("VerifyToken with the old key: %v", err)
+	}
+
+	// The key is rotated on the server.
+	backend.setKeys(rsaJWK("old", oldKey), ecJWK("new", newKey))
+	rotated := signTestToken(t, "ES256", "new", newKey, claims)
+
+	// Right after a fetch, an unknown kid does not trigger another one.
+	if _, err := client.VerifyToken(rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyToken right after a fetch = %v, want ErrInvalidToken", err)
+	}
+	if n := backend.fetchCount(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+
+	client.jwks.mu.Lock()
+	client.jwks.fetchedAt = time.Now().Add(-jwksMinRefresh)
+	client.jwks.mu.Unlock()
+
+	if _, err := client.VerifyToken(rotated); err != nil {
+		t.Fatalf("VerifyToken with the rotated key: %v", err)
+	}
+	if n := backend.fetchCount(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
@@ -0,0 +1,131 @@
+package WOWSQL
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// TOTPEnrollment holds what an authenticator app needs to add a new TOTP factor.
+type TOTPEnrollment struct {
+	FactorID string `json:"factor_id"`
+	Secret   string `json:"secret"`
+	// URI is the otpauth:// URI to encode in a QR code.
+	URI string `json:"uri"`
+	// QRCode is a ready-made QR code image (usually an SVG or data URI), if
+	// the server provides one.
+	QRCode string `json:"qr_code,omitempty"`
+}
+
+// EnrollTOTP starts enrolling a TOTP factor for the signed-in user. The factor
+// is not active until it is confirmed with VerifyTOTPEnrollment.
+func (c *AuthClient) EnrollTOTP() (*TOTPEnrollment, error) {
+	return c.EnrollTOTPContext(context.Background())
+}
+
+// EnrollTOTPContext is like EnrollTOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) EnrollTOTPContext(ctx context.Context) (*TOTPEnrollment, error) {
+	headers, err := c.bearerHeaders("enroll a TOTP factor")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/mfa/totp/enroll", nil, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var enrollment TOTPEnrollment
+	if err := json.Unmarshal(body, &enrollment); err != nil {
+		return nil, fmt.Errorf("failed to parse TOTP enrollment: %w", err)
+	}
+	return &enrollment, nil
+}
+
+// VerifyTOTPEnrollment activates a factor returned by EnrollTOTP using a code
+// from the authenticator app.
+func (c *AuthClient) VerifyTOTPEnrollment(factorID, code string) error {
+	return c.VerifyTOTPEnrollmentContext(context.Background(), factorID, code)
+}
+
+// VerifyTOTPEnrollmentContext is like VerifyTOTPEnrollment but uses ctx for cancellation and deadlines.
+func (c *AuthClient) VerifyTOTPEnrollmentContext(ctx context.Context, factorID, code string) error {
+	if factorID == "" || code == "" {
+		return fmt.Errorf("factorID and code are required")
+	}
+
+	headers, err := c.bearerHeaders("verify a TOTP factor")
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]string{
+		"factor_id": factorID,
+		"code":      code,
+	}
+	_, err = c.doRequest(ctx, "POST", "/mfa/totp/verify", payload, headers)
+	return err
+}
+
+// ChallengeTOTP completes a sign-in that failed with *MFARequiredError, using
+// the ChallengeID of that error and a code from the authenticator app. On
+// success the session is stored as with SignIn.
+func (c *AuthClient) ChallengeTOTP(challengeID, code string) (*AuthResult, error) {
+	return c.ChallengeTOTPContext(context.Background(), challengeID, code)
+}
+
+// ChallengeTOTPContext is like ChallengeTOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ChallengeTOTPContext(ctx context.Context, challengeID, code string) (*AuthResult, error) {
+	if challengeID == "" {
+		return nil, fmt.Errorf("challengeID is required")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+
+	payload := map[string]string{
+		"challenge_id": challengeID,
+		"code":         code,
+	}
+	body, err := c.doRequest(ctx, "POST", "/mfa/totp/challenge", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.sessionFromLoginResponse(body)
+}
+
+// mfaRequired returns an *MFARequiredError if a sign-in response or error
+// says a second factor is needed.
+func mfaRequired(resp *loginResponse, err error) error {
+	var challengeID string
+	var factors []string
+	var base WOWSQLError
+
+	switch {
+	case err != nil:
+		var apiErr *WOWSQLError
+		if !errors.As(err, &apiErr) || apiErr.Code != "mfa_required" {
+			return nil
+		}
+		base = *apiErr
+		challengeID, _ = apiErr.Response["challenge_id"].(string)
+		if list, ok := apiErr.Response["factors"].([]interface{}); ok {
+			for _, f := range list {
+				factors = append(factors, fmt.Sprint(f))
+			}
+		}
+	case resp != nil && resp.MFARequired:
+		base = WOWSQLError{Message: "multi-factor authentication is required", Code: "mfa_required"}
+		challengeID = resp.ChallengeID
+		factors = resp.Factors
+	default:
+		return nil
+	}
+
+	return &MFARequiredError{
+		WOWSQLError: base,
+		ChallengeID: challengeID,
+		Factors:     factors,
+	}
+}
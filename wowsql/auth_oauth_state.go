@@ -0,0 +1,140 @@
+package WOWSQL
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidOAuthState is returned when the state of an OAuth callback does
+// not match a flow started with GetOAuthAuthorizationURL, which indicates a
+// forged, replayed or expired callback.
+var ErrInvalidOAuthState = errors.New("invalid OAuth state")
+
+// OAuthStateTTL is how long a state generated by GetOAuthAuthorizationURL
+// can be exchanged.
+const OAuthStateTTL = 10 * time.Minute
+
+// OAuthFlowState is what is kept for an OAuth flow between the authorize
+// redirect and the callback.
+type OAuthFlowState struct {
+	Provider string `json:"provider"`
+	// RedirectURI is the redirect URL passed to GetOAuthAuthorizationURL.
+	RedirectURI string    `json:"redirect_uri"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (f OAuthFlowState) expired() bool {
+	return time.Since(f.CreatedAt) > OAuthStateTTL
+}
+
+// OAuthStateStore is implemented by session stores that can also keep
+// pending OAuth flows, keyed by their state, so the state survives the
+// redirect, e.g. across processes. When the configured SessionStore does not
+// implement it, flows are kept in memory by the AuthClient that started them
+// and shared with clients derived by WithAccessToken.
+type OAuthStateStore interface {
+	// SaveOAuthState stores flow under state.
+	SaveOAuthState(state string, flow OAuthFlowState) error
+	// TakeOAuthState returns the flow stored under state and removes it, so
+	// a state can only be used once. ok is false if there is none.
+	TakeOAuthState(state string) (flow OAuthFlowState, ok bool, err error)
+}
+
+// oauthFlowCache keeps pending OAuth flows in memory when the session store
+// cannot.
+type oauthFlowCache struct {
+	mu    sync.Mutex
+	flows map[string]OAuthFlowState
+}
+
+func (fc *oauthFlowCache) SaveOAuthState(state string, flow OAuthFlowState) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.flows == nil {
+		fc.flows = make(map[string]OAuthFlowState)
+	}
+	// Drop abandoned flows so the map does not grow without bound.
+	for s, f := range fc.flows {
+		if f.expired() {
+			delete(fc.flows, s)
+		}
+	}
+	fc.flows[state] = flow
+	return nil
+}
+
+func (fc *oauthFlowCache) TakeOAuthState(state string) (OAuthFlowState, bool, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	flow, ok := fc.flows[state]
+	delete(fc.flows, state)
+	return flow, ok, nil
+}
+
+func generateOAuthState() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// oauthStateStore returns where pending OAuth flows are kept.
+func (c *AuthClient) oauthStateStore() OAuthStateStore {
+	if store, ok := c.sessionStore.(OAuthStateStore); ok {
+		return store
+	}
+	return c.oauthFlows
+}
+
+// ExchangeOAuthCallbackWithState checks the state query parameter of an
+// OAuth callback against the flows started with GetOAuthAuthorizationURL and
+// then exchanges the code like ExchangeOAuthCallback. A state can be used
+// once, for the provider it was generated for, within OAuthStateTTL;
+// otherwise the error matches ErrInvalidOAuthState. redirectURI may be nil to
+// reuse the one the flow was started with; a different URI is rejected.
+//
+// Web apps using the redirect flow should use it to protect against CSRF.
+// When one client serves many users, also tie the state to the user's browser
+// session, e.g. with a cookie holding the State from GetOAuthAuthorizationURL.
+func (c *AuthClient) ExchangeOAuthCallbackWithState(provider, code, state string, redirectURI *string) (*AuthResult, error) {
+	return c.ExchangeOAuthCallbackWithStateContext(context.Background(), provider, code, state, redirectURI)
+}
+
+// ExchangeOAuthCallbackWithStateContext is like ExchangeOAuthCallbackWithState but uses ctx for cancellation and deadlines.
+func (c *AuthClient) ExchangeOAuthCallbackWithStateContext(ctx context.Context, provider, code, state string, redirectURI *string) (*AuthResult, error) {
+	if state == "" {
+		return nil, fmt.Errorf("%s OAuth callback has no state: %w", provider, ErrInvalidOAuthState)
+	}
+	flow, ok, err := c.oauthStateStore().TakeOAuthState(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OAuth state: %w", err)
+	}
+	if !ok || flow.Provider != provider {
+		return nil, fmt.Errorf("no %s OAuth flow in progress for this state: %w", provider, ErrInvalidOAuthState)
+	}
+	if flow.expired() {
+		return nil, fmt.Errorf("%s OAuth flow expired: %w", provider, ErrInvalidOAuthState)
+	}
+
+	payload := map[string]interface{}{
+		"code": code,
+	}
+	switch {
+	case redirectURI == nil:
+		if flow.RedirectURI != "" {
+			payload["redirect_uri"] = flow.RedirectURI
+		}
+	case flow.RedirectURI != "" && *redirectURI != flow.RedirectURI:
+		return nil, fmt.Errorf("redirect URI %q does not match %q used to start the %s OAuth flow", *redirectURI, flow.RedirectURI, provider)
+	default:
+		payload["redirect_uri"] = *redirectURI
+	}
+
+	return c.exchangeOAuthCallback(ctx, provider, payload)
+}
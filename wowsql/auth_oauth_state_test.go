@@ -0,0 +1,117 @@
+package WOWSQL
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOAuthTestServer(t *testing.T, redirectURIs *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/callback") {
+			var payload map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&payload)
+			uri, _ := payload["redirect_uri"].(string)
+			*redirectURIs = append(*redirectURIs, uri)
+			fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"bearer","expires_in":3600}`)
+			return
+		}
+		fmt.Fprintf(w, `{"authorization_url":"https://provider.example/authorize?state=%s","provider":"github"}`, r.URL.Query().Get("state"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExchangeOAuthCallbackWithState(t *testing.T) {
+	stores := map[string]func(t *testing.T) SessionStore{
+		"memory": func(t *testing.T) SessionStore { return nil },
+		"file": func(t *testing.T) SessionStore {
+			return NewFileSessionStore(filepath.Join(t.TempDir(), "session.json"))
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var redirectURIs []string
+			server := newOAuthTestServer(t, &redirectURIs)
+			client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test", SessionStore: newStore(t)})
+
+			alice, err := client.GetOAuthAuthorizationURL("github", "https://alice.example/callback")
+			if err != nil {
+				t.Fatalf("GetOAuthAuthorizationURL: %v", err)
+			}
+			bob, err := client.GetOAuthAuthorizationURL("github", "https://bob.example/callback")
+			if err != nil {
+				t.Fatalf("GetOAuthAuthorizationURL: %v", err)
+			}
+			if alice.State == "" || alice.State == bob.State {
+				t.Fatalf("states %q and %q should be distinct and non-empty", alice.State, bob.State)
+			}
+
+			// Each flow keeps its own redirect URI even though bob started later.
+			if _, err := client.ExchangeOAuthCallbackWithState("github", "code", alice.State, nil); err != nil {
+				t.Fatalf("exchange alice: %v", err)
+			}
+			if _, err := client.ExchangeOAuthCallbackWithState("github", "code", bob.State, nil); err != nil {
+				t.Fatalf("exchange bob: %v", err)
+			}
+			want := []string{"https://alice.example/callback", "https://bob.example/callback"}
+			if fmt.Sprint(redirectURIs) != fmt.Sprint(want) {
+				t.Errorf("redirect URIs sent %v, want %v", redirectURIs, want)
+			}
+
+			mismatch, err := client.GetOAuthAuthorizationURL("github", "https://alice.example/callback")
+			if err != nil {
+				t.Fatalf("GetOAuthAuthorizationURL: %v", err)
+			}
+			other := "https://evil.example/callback"
+			if _, err := client.ExchangeOAuthCallbackWithState("github", "code", mismatch.State, &other); err == nil {
+				t.Error("expected a different redirect URI to be rejected")
+			}
+
+			google, err := client.GetOAuthAuthorizationURL("google", "https://alice.example/callback")
+			if err != nil {
+				t.Fatalf("GetOAuthAuthorizationURL: %v", err)
+			}
+
+			tests := []struct {
+				name     string
+				provider string
+				state    string
+			}{
+				{"reused", "github", alice.State},
+				{"unknown", "github", "bm90LWEtc3RhdGU"},
+				{"empty", "github", ""},
+				{"wrong provider", "github", google.State},
+				{"path traversal", "github", "../session"},
+			}
+			for _, tt := range tests {
+				_, err := client.ExchangeOAuthCallbackWithState(tt.provider, "code", tt.state, nil)
+				if !errors.Is(err, ErrInvalidOAuthState) {
+					t.Errorf("%s: got %v, want ErrInvalidOAuthState", tt.name, err)
+				}
+			}
+			if len(redirectURIs) != 2 {
+				t.Errorf("rejected callbacks reached the server: %v", redirectURIs)
+			}
+		})
+	}
+}
+
+func TestExchangeOAuthCallbackWithStateExpired(t *testing.T) {
+	client := NewAuthClient(AuthConfig{ProjectURL: "https://myproject.wowsql.com", APIKey: "wowsql_anon_test"})
+	flow := OAuthFlowState{Provider: "github", CreatedAt: time.Now().Add(-OAuthStateTTL - time.Minute)}
+	if err := client.oauthStateStore().SaveOAuthState("expired", flow); err != nil {
+		t.Fatalf("SaveOAuthState: %v", err)
+	}
+	if _, err := client.ExchangeOAuthCallbackWithState("github", "code", "expired", nil); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("got %v, want ErrInvalidOAuthState", err)
+	}
+}
@@ -0,0 +1,96 @@
+package WOWSQL
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultPasswordMinLength is the client-side default for the minimum
+// password length.
+const DefaultPasswordMinLength = 8
+
+// PasswordPolicy describes the rules ValidatePassword checks.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters. Zero means
+	// DefaultPasswordMinLength.
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// RejectCommon rejects passwords found in a list of commonly used ones.
+	RejectCommon bool
+}
+
+// DefaultPasswordPolicy returns client-side default rules for instant form
+// feedback. They are not read from the server and may differ from what the
+// auth service enforces, so adjust them to the project's settings.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        DefaultPasswordMinLength,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RejectCommon:     true,
+	}
+}
+
+// ValidatePassword checks password against policy and returns a
+// human-readable message for every rule it breaks, or nil if it passes.
+// It is meant for instant feedback in forms; the server still enforces its
+// own rules.
+func ValidatePassword(password string, policy PasswordPolicy) []string {
+	var failures []string
+
+	minLength := policy.MinLength
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		failures = append(failures, fmt.Sprintf("must be at least %d characters long", minLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUpper {
+		failures = append(failures, "must contain an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		failures = append(failures, "must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		failures = append(failures, "must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		failures = append(failures, "must contain a symbol")
+	}
+	if policy.RejectCommon && commonPasswords[strings.ToLower(password)] {
+		failures = append(failures, "is too common")
+	}
+
+	return failures
+}
+
+var commonPasswords = map[string]bool{
+	"123456": true, "123456789": true, "12345678": true, "1234567890": true,
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"qwerty": true, "qwerty123": true, "qwertyuiop": true, "1q2w3e4r": true,
+	"abc123": true, "abcd1234": true, "111111": true, "000000": true,
+	"iloveyou": true, "letmein": true, "welcome": true, "welcome1": true,
+	"admin": true, "admin123": true, "monkey": true, "dragon": true,
+	"football": true, "baseball": true, "sunshine": true, "princess": true,
+	"superman": true, "trustno1": true, "changeme": true, "master": true,
+}
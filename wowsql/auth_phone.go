@@ -0,0 +1,87 @@
+package WOWSQL
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SignInWithPhone authenticates an existing user by phone number and password.
+func (c *AuthClient) SignInWithPhone(phone, password string) (*AuthResult, error) {
+	return c.SignInWithPhoneContext(context.Background(), phone, password)
+}
+
+// SignInWithPhoneContext is like SignInWithPhone but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SignInWithPhoneContext(ctx context.Context, phone, password string) (*AuthResult, error) {
+	if strings.TrimSpace(phone) == "" {
+		return nil, fmt.Errorf("phone is required")
+	}
+
+	payload := map[string]interface{}{
+		"phone":    phone,
+		"password": password,
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/phone/login", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sessionFromAuthResponse(body, "phone login")
+}
+
+// SendSMSOTP sends a one-time code to the given phone number by SMS.
+func (c *AuthClient) SendSMSOTP(phone string) (map[string]interface{}, error) {
+	return c.SendSMSOTPContext(context.Background(), phone)
+}
+
+// SendSMSOTPContext is like SendSMSOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) SendSMSOTPContext(ctx context.Context, phone string) (map[string]interface{}, error) {
+	if strings.TrimSpace(phone) == "" {
+		return nil, fmt.Errorf("phone is required")
+	}
+
+	payload := map[string]interface{}{
+		"phone": phone,
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/phone/otp/send", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse send SMS OTP response: %w", err)
+	}
+
+	return result, nil
+}
+
+// VerifySMSOTP verifies an SMS code and completes authentication.
+func (c *AuthClient) VerifySMSOTP(phone, otp string) (*AuthResult, error) {
+	return c.VerifySMSOTPContext(context.Background(), phone, otp)
+}
+
+// VerifySMSOTPContext is like VerifySMSOTP but uses ctx for cancellation and deadlines.
+func (c *AuthClient) VerifySMSOTPContext(ctx context.Context, phone, otp string) (*AuthResult, error) {
+	if strings.TrimSpace(phone) == "" {
+		return nil, fmt.Errorf("phone is required")
+	}
+	if strings.TrimSpace(otp) == "" {
+		return nil, fmt.Errorf("otp is required")
+	}
+
+	payload := map[string]interface{}{
+		"phone": phone,
+		"otp":   otp,
+	}
+
+	body, err := c.doRequest(ctx, "POST", "/phone/otp/verify", payload, nil)
+	if err != nil {
+		return nil, parseOTPError(err)
+	}
+
+	return c.sessionFromAuthResponse(body, "verify SMS OTP")
+}
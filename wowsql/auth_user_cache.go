@@ -0,0 +1,83 @@
+package WOWSQL
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// userCache remembers GetUser results per access token.
+type userCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user      AuthUser
+	fetchedAt time.Time
+}
+
+func (uc *userCache) get(token string) (*AuthUser, bool) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	entry, ok := uc.entries[token]
+	if !ok || time.Since(entry.fetchedAt) >= uc.ttl {
+		return nil, false
+	}
+	user := entry.user
+	return &user, true
+}
+
+func (uc *userCache) put(token string, user AuthUser) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.ttl <= 0 {
+		return
+	}
+	if uc.entries == nil {
+		uc.entries = make(map[string]userCacheEntry)
+	}
+	// Drop expired entries so tokens that are no longer used don't pile up.
+	now := time.Now()
+	for t, entry := range uc.entries {
+		if now.Sub(entry.fetchedAt) >= uc.ttl {
+			delete(uc.entries, t)
+		}
+	}
+	uc.entries[token] = userCacheEntry{user: user, fetchedAt: now}
+}
+
+func (uc *userCache) clear() {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.entries = nil
+}
+
+// WithUserCacheTTL makes GetUser reuse a fetched profile for ttl instead of
+// calling /me every time. Entries are keyed by access token and discarded
+// whenever the session changes. A ttl of zero disables the cache (the
+// default). Call it before the client is shared between goroutines.
+func (c *AuthClient) WithUserCacheTTL(ttl time.Duration) *AuthClient {
+	c.userCache.mu.Lock()
+	c.userCache.ttl = ttl
+	c.userCache.entries = nil
+	c.userCache.mu.Unlock()
+	return c
+}
+
+// InvalidateUserCache discards every cached user profile.
+func (c *AuthClient) InvalidateUserCache() {
+	c.userCache.clear()
+}
+
+// GetUserFresh is like GetUser but always fetches the profile from the
+// server, refreshing the cache with the result.
+func (c *AuthClient) GetUserFresh(tokenOverride ...string) (*AuthUser, error) {
+	return c.GetUserFreshContext(context.Background(), tokenOverride...)
+}
+
+// GetUserFreshContext is like GetUserFresh but uses ctx for cancellation and deadlines.
+func (c *AuthClient) GetUserFreshContext(ctx context.Context, tokenOverride ...string) (*AuthUser, error) {
+	return c.getUser(ctx, false, tokenOverride...)
+}
@@ -0,0 +1,35 @@
+package WOWSQL
+
+import "strings"
+
+// Default paths under which each API is mounted. Use the clients'
+// SetBasePath or WithBasePath to reach a backend that mounts an API elsewhere
+// or to pin a different API version.
+const (
+	DefaultAuthBasePath     = "/api/auth"
+	DefaultDatabaseBasePath = "/api/v1"
+	DefaultStorageBasePath  = "/api/v1/storage"
+	DefaultSchemaBasePath   = "/api/v2/schema"
+)
+
+// normalizeBasePath gives basePath a leading slash and no trailing slash.
+func normalizeBasePath(basePath string) string {
+	basePath = strings.TrimRight(strings.TrimSpace(basePath), "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return basePath
+}
+
+// rebase replaces the defaultBase prefix of path with basePath. Paths outside
+// defaultBase, or an empty basePath, leave path unchanged.
+func rebase(path, defaultBase, basePath string) string {
+	if basePath == "" || basePath == defaultBase || !strings.HasPrefix(path, defaultBase) {
+		return path
+	}
+	rest := path[len(defaultBase):]
+	if rest != "" && rest[0] != '/' && rest[0] != '?' {
+		return path
+	}
+	return basePath + rest
+}
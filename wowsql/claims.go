@@ -0,0 +1,98 @@
+package WOWSQL
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims holds the commonly used claims of a WoWSQL access token.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	// Audience holds the aud claim, which may be a string or a list.
+	Audience []string `json:"-"`
+	// Raw contains every claim in the payload, including the ones above.
+	Raw map[string]interface{} `json:"-"`
+}
+
+// Expiry returns the exp claim as a time, or the zero time if it is absent.
+func (c *Claims) Expiry() time.Time {
+	if c.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// HasAudience reports whether audience is listed in the aud claim.
+func (c *Claims) HasAudience(audience string) bool {
+	for _, aud := range c.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeAccessToken decodes the claims of the stored access token.
+//
+// ⚠️ The signature is NOT verified. Only use the result for display or
+// routing decisions, never for authorization.
+func (c *AuthClient) DecodeAccessToken() (*Claims, error) {
+	c.mu.RLock()
+	token := c.accessToken
+	c.mu.RUnlock()
+	if token == "" {
+		return nil, &WOWSQLError{Message: "access token is required to decode claims"}
+	}
+	return DecodeJWTClaims(token)
+}
+
+// DecodeJWTClaims decodes the payload segment of a JWT without verifying
+// its signature.
+//
+// ⚠️ The signature is NOT verified. Only use the result for display or
+// routing decisions, never for authorization.
+func DecodeJWTClaims(token string) (*Claims, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("malformed token: expected 3 segments, got %d", len(segments))
+	}
+
+	payload, err := decodeSegment(segments[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token payload: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+	if err := json.Unmarshal(payload, &claims.Raw); err != nil {
+		return nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+	switch aud := claims.Raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []interface{}:
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				claims.Audience = append(claims.Audience, s)
+			}
+		}
+	}
+
+	return &claims, nil
+}
+
+// decodeSegment decodes a base64url JWT segment, with or without padding.
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
@@ -2,18 +2,25 @@ package WOWSQL
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 // Client represents the WOWSQL database client
 type Client struct {
 	projectURL string
-	apiKey     string
+	basePath   string // empty for DefaultDatabaseBasePath
 	httpClient *http.Client
+
+	interceptors []RequestInterceptor
+
+	mu     sync.RWMutex // guards apiKey
+	apiKey string
 }
 
 // NewClient creates a new WOWSQL client
@@ -49,12 +56,58 @@ func (c *Client) Table(tableName string) *Table {
 // Schema returns a new SchemaClient for schema management operations
 // ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
 func (c *Client) Schema() *SchemaClient {
-	return NewSchemaClient(c.projectURL, c.apiKey)
+	schema := NewSchemaClient(c.projectURL, c.getAPIKey())
+	schema.interceptors = append(schema.interceptors, c.interceptors...)
+	return schema
+}
+
+// WithRequestInterceptor adds fn to the interceptors run on every request
+// before it is sent, in the order they were added. Schema clients created
+// afterwards inherit them. Call it before the client is shared between
+// goroutines.
+func (c *Client) WithRequestInterceptor(fn RequestInterceptor) *Client {
+	c.interceptors = append(c.interceptors, fn)
+	return c
+}
+
+// SetTimeout sets the timeout for each request. Calls made with a context
+// that has a deadline use that deadline instead. Call it before the client is
+// shared between goroutines.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetBasePath sets the path the database API is mounted under, replacing
+// DefaultDatabaseBasePath, e.g. for a self-hosted backend. An empty basePath
+// restores the default. Call it before the client is shared between
+// goroutines.
+func (c *Client) SetBasePath(basePath string) {
+	c.basePath = normalizeBasePath(basePath)
+}
+
+// SetAPIKey replaces the API key sent with every request, e.g. when keys are
+// rotated. It is safe to call while other goroutines use the client. Schema
+// clients created earlier keep the key they were created with.
+func (c *Client) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = key
+}
+
+func (c *Client) getAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.apiKey
 }
 
 // ListTables lists all tables in the database
 func (c *Client) ListTables() ([]string, error) {
-	resp, err := c.doRequest("GET", "/api/v1/tables", nil)
+	return c.ListTablesContext(context.Background())
+}
+
+// ListTablesContext is like ListTables but uses ctx for cancellation and deadlines.
+func (c *Client) ListTablesContext(ctx context.Context) ([]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/tables", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +124,12 @@ func (c *Client) ListTables() ([]string, error) {
 
 // GetTableSchema gets the schema information for a table
 func (c *Client) GetTableSchema(tableName string) (*TableSchema, error) {
-	resp, err := c.doRequest("GET", fmt.Sprintf("/api/v1/tables/%s/schema", tableName), nil)
+	return c.GetTableSchemaContext(context.Background(), tableName)
+}
+
+// GetTableSchemaContext is like GetTableSchema but uses ctx for cancellation and deadlines.
+func (c *Client) GetTableSchemaContext(ctx context.Context, tableName string) (*TableSchema, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/tables/%s/schema", tableName), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -86,11 +144,16 @@ func (c *Client) GetTableSchema(tableName string) (*TableSchema, error) {
 
 // Query executes a raw SQL query (read-only)
 func (c *Client) Query(sql string) ([]map[string]interface{}, error) {
+	return c.QueryContext(context.Background(), sql)
+}
+
+// QueryContext is like Query but uses ctx for cancellation and deadlines.
+func (c *Client) QueryContext(ctx context.Context, sql string) ([]map[string]interface{}, error) {
 	body := map[string]interface{}{
 		"sql": sql,
 	}
 
-	resp, err := c.doRequest("POST", "/api/v1/query", body)
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/query", body)
 	if err != nil {
 		return nil, err
 	}
@@ -105,9 +168,27 @@ func (c *Client) Query(sql string) ([]map[string]interface{}, error) {
 	return result.Data, nil
 }
 
+// Ping checks that the project is reachable and the API key is accepted,
+// using a lightweight authenticated request. Use it at startup to fail fast;
+// the error says whether the key was rejected or the host is unreachable.
+func (c *Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses ctx for cancellation and deadlines.
+func (c *Client) PingContext(ctx context.Context) error {
+	_, err := c.doRequest(ctx, "GET", "/api/v1/tables", nil)
+	return pingError(err)
+}
+
 // Health checks the API health
 func (c *Client) Health() (map[string]interface{}, error) {
-	resp, err := c.doRequest("GET", "/api/v1/health", nil)
+	return c.HealthContext(context.Background())
+}
+
+// HealthContext is like Health but uses ctx for cancellation and deadlines.
+func (c *Client) HealthContext(ctx context.Context) (map[string]interface{}, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/health", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +202,7 @@ func (c *Client) Health() (map[string]interface{}, error) {
 }
 
 // doRequest performs an HTTP request
-func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error) {
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -131,17 +212,20 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	url := c.projectURL + path
-	req, err := http.NewRequest(method, url, bodyReader)
+	url := c.projectURL + rebase(path, DefaultDatabaseBasePath, c.basePath)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+c.getAPIKey())
+	if err := intercept(c.interceptors, req); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClientFor(ctx, c.httpClient).Do(req)
 	if err != nil {
 		return nil, &NetworkError{Err: err}
 	}
@@ -153,7 +237,7 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, parseError(resp.StatusCode, respBody)
+		return nil, withRetryAfter(parseError(resp.StatusCode, respBody), resp.Header)
 	}
 
 	return respBody, nil
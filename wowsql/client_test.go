@@ -0,0 +1,37 @@
+package WOWSQL
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientContextDeadlineOverridesTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, "wowsql_anon_test")
+	client.SetTimeout(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Table("users").Select("*").ExecuteContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExecuteContext = %v, want context.DeadlineExceeded", err)
+	}
+
+	client.SetTimeout(50 * time.Millisecond)
+	if _, err := client.Table("users").Insert(map[string]interface{}{"name": "Ada"}); err == nil {
+		t.Error("Insert succeeded, want a timeout")
+	}
+}
@@ -1,15 +1,38 @@
 package WOWSQL
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
 )
 
+// Sentinel errors matched by errors.Is against API errors by status code.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// ErrServiceKeyRequired is matched by errors.Is when a schema operation was
+// rejected with 403 because the client is not using a SERVICE ROLE key, or
+// when a storage admin operation is called without one.
+var ErrServiceKeyRequired = errors.New("service role key required")
+
 // WOWSQLError represents a base WOWSQL error
 type WOWSQLError struct {
 	Message    string
 	StatusCode int
 	Response   map[string]interface{}
+	RawBody    []byte
+	// Code is the machine-readable error code from the response, if any.
+	Code string
 }
 
 func (e *WOWSQLError) Error() string {
@@ -19,19 +42,115 @@ func (e *WOWSQLError) Error() string {
 	return fmt.Sprintf("WOWSQLError: %s", e.Message)
 }
 
+// Is reports whether the error matches one of the status code sentinels.
+func (e *WOWSQLError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == 401
+	case ErrForbidden:
+		return e.StatusCode == 403
+	case ErrNotFound:
+		return e.StatusCode == 404
+	case ErrConflict:
+		return e.StatusCode == 409
+	case ErrRateLimited:
+		return e.StatusCode == 429
+	}
+	return false
+}
+
 // AuthenticationError represents authentication errors
 type AuthenticationError struct {
 	WOWSQLError
 }
 
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *AuthenticationError) Unwrap() error {
+	return &e.WOWSQLError
+}
+
 // NotFoundError represents not found errors
 type NotFoundError struct {
 	WOWSQLError
 }
 
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *NotFoundError) Unwrap() error {
+	return &e.WOWSQLError
+}
+
 // RateLimitError represents rate limit errors
 type RateLimitError struct {
 	WOWSQLError
+	// RetryAfter is how long the server asked the caller to wait, taken
+	// from the Retry-After header. Zero if the header was absent.
+	RetryAfter time.Duration
+}
+
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *RateLimitError) Unwrap() error {
+	return &e.WOWSQLError
+}
+
+// ValidationError represents request validation errors (422), such as a
+// weak password or an invalid email on sign-up.
+type ValidationError struct {
+	WOWSQLError
+	// Fields maps each invalid input field to its validation messages.
+	Fields map[string][]string
+}
+
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *ValidationError) Unwrap() error {
+	return &e.WOWSQLError
+}
+
+// OTPError represents a failed OTP verification along with how many attempts
+// remain before lockout and, if locked, when the lockout ends.
+type OTPError struct {
+	WOWSQLError
+	AttemptsRemaining int
+	LockedUntil       time.Time
+	Err               error
+}
+
+// IsLocked reports whether further attempts are currently blocked.
+func (e *OTPError) IsLocked() bool {
+	return e.LockedUntil.After(time.Now())
+}
+
+// Unwrap returns the original API error so errors.As still matches it.
+func (e *OTPError) Unwrap() error {
+	return e.Err
+}
+
+// MFARequiredError is returned by SignIn when the account has a second
+// factor enabled. Prompt for a code and pass it to ChallengeTOTP.
+type MFARequiredError struct {
+	WOWSQLError
+	// ChallengeID identifies this sign-in attempt; pass it to ChallengeTOTP.
+	ChallengeID string
+	// Factors lists the factor types the user can complete, e.g. "totp".
+	Factors []string
+}
+
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *MFARequiredError) Unwrap() error {
+	return &e.WOWSQLError
+}
+
+// UnverifiedError is returned by SignUp when the account was created but the
+// email must be verified before the user can sign in. The AuthResult
+// returned with it carries the user and an empty session. Call
+// ResendVerification to send the email again.
+type UnverifiedError struct {
+	WOWSQLError
+	Email string
+}
+
+// Unwrap exposes the underlying WOWSQLError to errors.As.
+func (e *UnverifiedError) Unwrap() error {
+	return &e.WOWSQLError
 }
 
 // NetworkError represents network errors
@@ -47,11 +166,49 @@ func (e *NetworkError) Unwrap() error {
 	return e.Err
 }
 
+// IsTimeout reports whether the request failed because a timeout or context
+// deadline expired.
+func (e *NetworkError) IsTimeout() bool {
+	return isTimeout(e.Err)
+}
+
+// IsConnectionRefused reports whether the server refused the connection.
+func (e *NetworkError) IsConnectionRefused() bool {
+	return isConnectionRefused(e.Err)
+}
+
+// IsDNSError reports whether the server's host name could not be resolved.
+func (e *NetworkError) IsDNSError() bool {
+	var dnsErr *net.DNSError
+	return errors.As(e.Err, &dnsErr)
+}
+
+// wsaeConnRefused is the Windows error for a refused connection. It does not
+// match syscall.ECONNREFUSED, which Windows only defines for compatibility.
+const wsaeConnRefused = syscall.Errno(10061)
+
+func isConnectionRefused(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return false
+	}
+	return errors.Is(opErr.Err, syscall.ECONNREFUSED) || errors.Is(opErr.Err, wsaeConnRefused)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // StorageError represents storage errors
 type StorageError struct {
 	Message    string
 	StatusCode int
 	Response   map[string]interface{}
+	Code       string
 	Err        error
 }
 
@@ -69,6 +226,30 @@ func (e *StorageError) Unwrap() error {
 	return e.Err
 }
 
+// IsTimeout reports whether the request failed because a timeout or context
+// deadline expired.
+func (e *StorageError) IsTimeout() bool {
+	return isTimeout(e.Err)
+}
+
+// IsConnectionRefused reports whether the server refused the connection.
+func (e *StorageError) IsConnectionRefused() bool {
+	return isConnectionRefused(e.Err)
+}
+
+// ChecksumMismatchError is returned when transferred data does not match
+// the expected hash.
+type ChecksumMismatchError struct {
+	Key       string
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("ChecksumMismatchError: %s %s mismatch (expected %s, got %s)", e.Key, e.Algorithm, e.Expected, e.Actual)
+}
+
 // StorageLimitExceededError represents storage limit exceeded errors
 type StorageLimitExceededError struct {
 	Message        string
@@ -76,35 +257,88 @@ type StorageLimitExceededError struct {
 	AvailableBytes int64
 	StatusCode     int
 	Response       map[string]interface{}
+	Code           string
 }
 
 func (e *StorageLimitExceededError) Error() string {
 	if e.RequiredBytes > 0 && e.AvailableBytes > 0 {
 		return fmt.Sprintf("StorageLimitExceededError: %s (Required: %s, Available: %s)",
 			e.Message,
-			formatBytes(e.RequiredBytes),
-			formatBytes(e.AvailableBytes))
+			FormatBytes(e.RequiredBytes),
+			FormatBytes(e.AvailableBytes))
 	}
 	return fmt.Sprintf("StorageLimitExceededError: %s", e.Message)
 }
 
-// parseError parses an error response
-func parseError(statusCode int, body []byte) error {
-	var errorResponse map[string]interface{}
-	_ = json.Unmarshal(body, &errorResponse)
+// AlreadyProvisionedError is returned by ProvisionStorage when the project
+// already has storage (409). The existing credentials are not returned again.
+type AlreadyProvisionedError struct {
+	StorageError
+}
 
-	message := "Request failed"
-	if msg, ok := errorResponse["error"].(string); ok {
-		message = msg
-	} else if msg, ok := errorResponse["message"].(string); ok {
-		message = msg
-	} else if msg, ok := errorResponse["detail"].(string); ok {
-		message = msg
-	}
+func (e *AlreadyProvisionedError) Error() string {
+	return fmt.Sprintf("AlreadyProvisionedError: %s", e.Message)
+}
 
-	if message == "Request failed" {
-		message = fmt.Sprintf("Request failed with status %d", statusCode)
+// Unwrap exposes the underlying StorageError to errors.As.
+func (e *AlreadyProvisionedError) Unwrap() error {
+	return &e.StorageError
+}
+
+// PermissionError is returned by HeadFile and FileExists when the caller is
+// not allowed to see the object (403), so it can be told apart from a
+// missing object. It also matches errors.Is(err, ErrForbidden).
+type PermissionError struct {
+	StorageError
+	Key string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("PermissionError: access to %q denied: %s", e.Key, e.Message)
+}
+
+// Unwrap exposes the underlying StorageError to errors.As.
+func (e *PermissionError) Unwrap() error {
+	return &e.StorageError
+}
+
+// BatchStatementError reports which statement of an ExecuteBatch failed.
+// The whole batch was rolled back.
+type BatchStatementError struct {
+	// Index is the zero-based position of the failed statement.
+	Index     int
+	Statement string
+	Err       error
+}
+
+func (e *BatchStatementError) Error() string {
+	return fmt.Sprintf("BatchStatementError: statement %d failed, batch rolled back: %v", e.Index, e.Err)
+}
+
+// Unwrap exposes the underlying API error.
+func (e *BatchStatementError) Unwrap() error {
+	return e.Err
+}
+
+// pingError explains why a Ping failed: a rejected API key or an unreachable
+// project URL. The original error stays available through errors.As.
+func pingError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+		return fmt.Errorf("ping failed: API key was rejected: %w", err)
 	}
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return fmt.Errorf("ping failed: project URL is unreachable: %w", err)
+	}
+	return fmt.Errorf("ping failed: %w", err)
+}
+
+// parseError parses an error response
+func parseError(statusCode int, body []byte) error {
+	errorResponse, message, code := parseErrorEnvelope(statusCode, body)
 
 	switch statusCode {
 	case 401, 403:
@@ -113,6 +347,8 @@ func parseError(statusCode int, body []byte) error {
 				Message:    message,
 				StatusCode: statusCode,
 				Response:   errorResponse,
+				RawBody:    body,
+				Code:       code,
 			},
 		}
 	case 404:
@@ -121,7 +357,24 @@ func parseError(statusCode int, body []byte) error {
 				Message:    message,
 				StatusCode: statusCode,
 				Response:   errorResponse,
+				RawBody:    body,
+				Code:       code,
+			},
+		}
+	case 422:
+		fields := parseValidationFields(errorResponse)
+		if message == fmt.Sprintf("Request failed with status %d", statusCode) && len(fields) > 0 {
+			message = "Validation failed"
+		}
+		return &ValidationError{
+			WOWSQLError: WOWSQLError{
+				Message:    message,
+				StatusCode: statusCode,
+				Response:   errorResponse,
+				RawBody:    body,
+				Code:       code,
 			},
+			Fields: fields,
 		}
 	case 429:
 		return &RateLimitError{
@@ -129,6 +382,8 @@ func parseError(statusCode int, body []byte) error {
 				Message:    message,
 				StatusCode: statusCode,
 				Response:   errorResponse,
+				RawBody:    body,
+				Code:       code,
 			},
 		}
 	default:
@@ -136,40 +391,136 @@ func parseError(statusCode int, body []byte) error {
 			Message:    message,
 			StatusCode: statusCode,
 			Response:   errorResponse,
+			RawBody:    body,
+			Code:       code,
 		}
 	}
 }
 
-// parseStorageError parses a storage error response
-func parseStorageError(statusCode int, body []byte) error {
+// withRetryAfter records the Retry-After header on err if it is a rate limit
+// error.
+func withRetryAfter(err error, header http.Header) error {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		rateLimitErr.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+	}
+	return err
+}
+
+// parseErrorEnvelope extracts the message and code from any of the server's
+// error shapes: {"detail": "..."}, {"error": "..."}, {"message": "..."} or
+// {"error": {"message": "...", "code": "..."}}.
+func parseErrorEnvelope(statusCode int, body []byte) (map[string]interface{}, string, string) {
 	var errorResponse map[string]interface{}
 	_ = json.Unmarshal(body, &errorResponse)
 
-	message := "Request failed"
-	if msg, ok := errorResponse["error"].(string); ok {
-		message = msg
-	} else if msg, ok := errorResponse["message"].(string); ok {
-		message = msg
-	} else if msg, ok := errorResponse["detail"].(string); ok {
-		message = msg
+	message := ""
+	code, _ := errorResponse["code"].(string)
+	for _, key := range []string{"error", "message", "detail"} {
+		switch v := errorResponse[key].(type) {
+		case string:
+			message = v
+		case map[string]interface{}:
+			message, _ = v["message"].(string)
+			if c, ok := v["code"].(string); ok {
+				code = c
+			}
+		}
+		if message != "" {
+			break
+		}
 	}
 
-	if message == "Request failed" {
+	if message == "" {
 		message = fmt.Sprintf("Request failed with status %d", statusCode)
 	}
+	return errorResponse, message, code
+}
+
+// parseValidationFields extracts per-field messages from either a
+// {"detail": [{"loc": [...], "msg": "..."}]} or an {"errors": {"field": [...]}} body.
+func parseValidationFields(errorResponse map[string]interface{}) map[string][]string {
+	fields := make(map[string][]string)
+
+	if details, ok := errorResponse["detail"].([]interface{}); ok {
+		for _, d := range details {
+			item, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			msg, _ := item["msg"].(string)
+			field := ""
+			if loc, ok := item["loc"].([]interface{}); ok && len(loc) > 0 {
+				field = fmt.Sprint(loc[len(loc)-1])
+			}
+			fields[field] = append(fields[field], msg)
+		}
+	}
+
+	if errs, ok := errorResponse["errors"].(map[string]interface{}); ok {
+		for field, v := range errs {
+			switch msgs := v.(type) {
+			case string:
+				fields[field] = append(fields[field], msgs)
+			case []interface{}:
+				for _, m := range msgs {
+					fields[field] = append(fields[field], fmt.Sprint(m))
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+// parseOTPError enriches an OTP verification failure with the attempts
+// remaining and lockout time reported by the server, if present.
+func parseOTPError(err error) error {
+	var apiErr *WOWSQLError
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		return err
+	}
+
+	attempts, hasAttempts := apiErr.Response["attempts_remaining"].(float64)
+	lockedUntil, hasLock := apiErr.Response["locked_until"].(string)
+	if !hasAttempts && !hasLock {
+		return err
+	}
+
+	otpErr := &OTPError{
+		WOWSQLError:       *apiErr,
+		AttemptsRemaining: int(attempts),
+		Err:               err,
+	}
+	if hasLock {
+		if t, parseErr := time.Parse(time.RFC3339, lockedUntil); parseErr == nil {
+			otpErr.LockedUntil = t
+		}
+	}
+	return otpErr
+}
+
+// parseStorageError parses a storage error response
+func parseStorageError(statusCode int, body []byte) error {
+	errorResponse, message, code := parseErrorEnvelope(statusCode, body)
 
 	if statusCode == 413 {
 		return &StorageLimitExceededError{
 			Message:    message,
 			StatusCode: statusCode,
 			Response:   errorResponse,
+			Code:       code,
 		}
 	}
 
+	// Err carries the same failure as a typed WOWSQLError, so storage errors
+	// match errors.As(err, &*WOWSQLError) and the status code sentinels.
 	return &StorageError{
 		Message:    message,
 		StatusCode: statusCode,
 		Response:   errorResponse,
+		Code:       code,
+		Err:        parseError(statusCode, body),
 	}
 }
 
@@ -0,0 +1,186 @@
+package WOWSQL
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNetworkErrorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test", Timeout: 50 * time.Millisecond})
+	_, err := client.SignIn("user@example.com", "password")
+
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("expected *NetworkError, got %T: %v", err, err)
+	}
+	if !netErr.IsTimeout() {
+		t.Errorf("IsTimeout() = false for %v", err)
+	}
+	if netErr.IsConnectionRefused() {
+		t.Errorf("IsConnectionRefused() = true for %v", err)
+	}
+}
+
+func TestNetworkErrorConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client := NewAuthClient(AuthConfig{ProjectURL: "http://" + addr, APIKey: "wowsql_anon_test"})
+	_, err = client.SignIn("user@example.com", "password")
+
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("expected *NetworkError, got %T: %v", err, err)
+	}
+	if !netErr.IsConnectionRefused() {
+		t.Errorf("IsConnectionRefused() = false for %v", err)
+	}
+	if netErr.IsTimeout() {
+		t.Errorf("IsTimeout() = true for %v", err)
+	}
+
+	storage := NewStorageClient("http://"+addr, "wowsql_anon_test")
+	_, err = storage.GetFileInfo("a.txt")
+	var storageErr *StorageError
+	if !errors.As(err, &storageErr) || !storageErr.IsConnectionRefused() {
+		t.Errorf("expected a refused StorageError, got %T: %v", err, err)
+	}
+}
+
+func TestRateLimitErrorRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"seconds", "7", 7 * time.Second, 7 * time.Second},
+		{"http date", time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat), 85 * time.Second, 90 * time.Second},
+		{"absent", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"detail":"Too many requests"}`))
+			}))
+			defer server.Close()
+
+			client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"})
+			_, err := client.ForgotPassword("user@example.com")
+
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Fatalf("expected *RateLimitError, got %T: %v", err, err)
+			}
+			if rateLimitErr.RetryAfter < tt.min || rateLimitErr.RetryAfter > tt.max {
+				t.Errorf("RetryAfter = %v, want between %v and %v", rateLimitErr.RetryAfter, tt.min, tt.max)
+			}
+			if !errors.Is(err, ErrRateLimited) {
+				t.Errorf("error does not match ErrRateLimited: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantMessage string
+		wantCode    string
+	}{
+		{"detail", `{"detail":"Table not found"}`, "Table not found", ""},
+		{"error string", `{"error":"Bad key","code":"invalid_key"}`, "Bad key", "invalid_key"},
+		{"message", `{"message":"Slow down"}`, "Slow down", ""},
+		{"nested error", `{"error":{"message":"Quota exceeded","code":"quota"}}`, "Quota exceeded", "quota"},
+		{"error wins over detail", `{"error":"first","detail":"second"}`, "first", ""},
+		{"validation list", `{"detail":[{"loc":["body","email"],"msg":"invalid"}]}`, "Request failed with status 400", ""},
+		{"not json", `<html>Bad Gateway</html>`, "Request failed with status 400", ""},
+		{"empty", ``, "Request failed with status 400", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, message, code := parseErrorEnvelope(400, []byte(tt.body))
+			if message != tt.wantMessage || code != tt.wantCode {
+				t.Errorf("got (%q, %q), want (%q, %q)", message, code, tt.wantMessage, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestParseErrorTypes(t *testing.T) {
+	err := parseError(401, []byte(`{"detail":"Invalid token"}`))
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) || authErr.Message != "Invalid token" || !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("401: got %T %v", err, err)
+	}
+
+	err = parseError(404, []byte(`{"error":"No such row"}`))
+	var notFoundErr *NotFoundError
+	if !errors.As(err, &notFoundErr) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("404: got %T %v", err, err)
+	}
+
+	err = parseError(422, []byte(`{"detail":[{"loc":["body","email"],"msg":"not a valid email"}]}`))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("422: got %T %v", err, err)
+	}
+	if validationErr.Message != "Validation failed" || len(validationErr.Fields["email"]) != 1 {
+		t.Errorf("422: got message %q and fields %v", validationErr.Message, validationErr.Fields)
+	}
+
+	err = parseError(500, []byte(`{"message":"boom","code":"internal"}`))
+	var apiErr *WOWSQLError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 || apiErr.Code != "internal" || string(apiErr.RawBody) == "" {
+		t.Errorf("500: got %T %v", err, err)
+	}
+
+	err = parseStorageError(404, []byte(`{"detail":"File not found"}`))
+	var storageErr *StorageError
+	if !errors.As(err, &storageErr) || storageErr.Message != "File not found" || !errors.Is(err, ErrNotFound) {
+		t.Errorf("storage 404: got %T %v", err, err)
+	}
+}
+
+func TestIsConnectionRefused(t *testing.T) {
+	dial := func(errno syscall.Errno) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unix", dial(syscall.ECONNREFUSED), true},
+		{"windows", dial(wsaeConnRefused), true},
+		{"unreachable", dial(syscall.EHOSTUNREACH), false},
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNREFUSED)}, false},
+		{"dns", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "nope.invalid"}}, false},
+	}
+	for _, tt := range tests {
+		if got := (&NetworkError{Err: tt.err}).IsConnectionRefused(); got != tt.want {
+			t.Errorf("%s: IsConnectionRefused() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package WOWSQL
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// IdempotencyKeyHeader is the request header that carries an idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random UUID (version 4) for use as an
+// idempotency key. Generate one yourself when you want to log the key before
+// the request is sent. It panics if the system random source fails.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate idempotency key: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,22 @@
+package WOWSQL
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RequestInterceptor inspects or changes an outgoing request just before it
+// is sent, e.g. to add tracing headers, tenant IDs or a request signature.
+// It runs after the client has set its own headers, so it can override them.
+// Returning an error aborts the request.
+type RequestInterceptor func(req *http.Request) error
+
+// intercept runs the interceptors on req in the order they were added.
+func intercept(interceptors []RequestInterceptor, req *http.Request) error {
+	for _, interceptor := range interceptors {
+		if err := interceptor(req); err != nil {
+			return fmt.Errorf("request interceptor failed: %w", err)
+		}
+	}
+	return nil
+}
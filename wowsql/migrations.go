@@ -0,0 +1,163 @@
+package WOWSQL
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MigrationsTable is the table in which applied migration versions are recorded.
+const MigrationsTable = "schema_migrations"
+
+// Migration is a single schema change. IDs must be unique and are applied in
+// the order the migrations are given, so use sortable IDs such as
+// "0001_create_users".
+type Migration struct {
+	ID string
+	Up string
+}
+
+// MigrationStatus describes a migration recorded in MigrationsTable
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt string
+}
+
+// Migrations applies ordered migrations through a SchemaClient and tracks
+// the applied versions, so running the same list again is a no-op.
+//
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+type Migrations struct {
+	schema *SchemaClient
+
+	// DryRun prints the SQL that Apply would run to Output instead of
+	// executing it.
+	DryRun bool
+	// Output receives dry-run SQL. Defaults to os.Stdout.
+	Output io.Writer
+}
+
+// NewMigrations creates a migration runner on top of schema
+func NewMigrations(schema *SchemaClient) *Migrations {
+	return &Migrations{schema: schema}
+}
+
+// Apply runs every migration that has not been applied yet, in order. Each
+// migration is run and recorded in a single transaction with
+// SchemaClient.ExecuteBatch, so a failed migration leaves no trace. It stops
+// at the first failure.
+func (m *Migrations) Apply(migrations []Migration) error {
+	return m.ApplyContext(context.Background(), migrations)
+}
+
+// ApplyContext is like Apply but uses ctx for cancellation and deadlines.
+func (m *Migrations) ApplyContext(ctx context.Context, migrations []Migration) error {
+	seen := make(map[string]bool, len(migrations))
+	for _, migration := range migrations {
+		if migration.ID == "" {
+			return fmt.Errorf("migration ID is required")
+		}
+		if seen[migration.ID] {
+			return fmt.Errorf("duplicate migration ID %q", migration.ID)
+		}
+		seen[migration.ID] = true
+	}
+
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied := map[string]bool{}
+	if exists {
+		statuses, err := m.StatusContext(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied[status.ID] = true
+		}
+	} else if err := m.exec(ctx, createMigrationsTableSQL); err != nil {
+		return fmt.Errorf("failed to create %s: %w", MigrationsTable, err)
+	}
+
+	for _, migration := range migrations {
+		if applied[migration.ID] {
+			continue
+		}
+		// Run the migration and record it in one transaction, so a failure
+		// to record it rolls the migration back too.
+		statements := []string{
+			strings.TrimRight(strings.TrimSpace(migration.Up), "; \t\r\n"),
+			fmt.Sprintf("INSERT INTO %s (version) VALUES (%s)", MigrationsTable, quoteSQLString(migration.ID)),
+		}
+		if m.DryRun {
+			fmt.Fprintf(m.output(), "-- migration %s\n%s;\n%s;\n", migration.ID, statements[0], statements[1])
+			continue
+		}
+		if _, err := m.schema.ExecuteBatchContext(ctx, statements); err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the migrations recorded as applied, oldest first
+func (m *Migrations) Status() ([]MigrationStatus, error) {
+	return m.StatusContext(context.Background())
+}
+
+// StatusContext is like Status but uses ctx for cancellation and deadlines.
+func (m *Migrations) StatusContext(ctx context.Context) ([]MigrationStatus, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT version, applied_at FROM %s ORDER BY applied_at, version", MigrationsTable)
+	result, err := m.schema.ExecuteQueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		status := MigrationStatus{Applied: true}
+		if len(row) > 0 {
+			status.ID = fmt.Sprint(row[0])
+		}
+		if len(row) > 1 && row[1] != nil {
+			status.AppliedAt = fmt.Sprint(row[1])
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+const createMigrationsTableSQL = "CREATE TABLE IF NOT EXISTS " + MigrationsTable +
+	" (version VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"
+
+func (m *Migrations) tableExists(ctx context.Context) (bool, error) {
+	return m.schema.TableExistsContext(ctx, MigrationsTable)
+}
+
+// exec runs sql, or prints it in dry-run mode.
+func (m *Migrations) exec(ctx context.Context, sql string) error {
+	if m.DryRun {
+		_, err := fmt.Fprintf(m.output(), "%s;\n", sql)
+		return err
+	}
+	_, err := m.schema.ExecuteSQLContext(ctx, sql)
+	return err
+}
+
+func (m *Migrations) output() io.Writer {
+	if m.Output != nil {
+		return m.Output
+	}
+	return os.Stdout
+}
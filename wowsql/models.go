@@ -1,6 +1,9 @@
 package WOWSQL
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // QueryResponse represents a query response
 type QueryResponse struct {
@@ -35,14 +38,23 @@ type TableSchema struct {
 	Columns    []ColumnInfo `json:"columns"`
 	PrimaryKey *string      `json:"primary_key,omitempty"`
 	RowCount   *int         `json:"row_count,omitempty"`
+	Indexes    []IndexInfo  `json:"indexes,omitempty"`
 }
 
 // ColumnInfo represents column information
 type ColumnInfo struct {
-	Name     string      `json:"name"`
-	Type     string      `json:"type"`
-	Nullable bool        `json:"nullable"`
-	Default  interface{} `json:"default,omitempty"`
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Nullable   bool        `json:"nullable"`
+	Default    interface{} `json:"default,omitempty"`
+	PrimaryKey bool        `json:"primary_key,omitempty"`
+}
+
+// IndexInfo represents an index on a table
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
 }
 
 // StorageQuota represents storage quota information
@@ -80,6 +92,31 @@ func (sq *StorageQuota) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UsedPercent returns the share of the quota in use, from 0 to 100. It falls
+// back to the server's UsagePercentage when the quota size is unknown.
+func (sq *StorageQuota) UsedPercent() float64 {
+	if sq.StorageQuotaBytes <= 0 {
+		return sq.UsagePercentage
+	}
+	return float64(sq.StorageUsedBytes) / float64(sq.StorageQuotaBytes) * 100
+}
+
+// Used returns the used storage formatted for display, e.g. "1.50 GB".
+func (sq *StorageQuota) Used() string {
+	return FormatBytes(sq.StorageUsedBytes)
+}
+
+// Available returns the available storage formatted for display.
+func (sq *StorageQuota) Available() string {
+	return FormatBytes(sq.StorageAvailableBytes)
+}
+
+// IsNearLimit reports whether usage is at or above threshold percent, e.g.
+// IsNearLimit(90) once 90% of the quota is used.
+func (sq *StorageQuota) IsNearLimit(threshold float64) bool {
+	return sq.UsedPercent() >= threshold
+}
+
 // StorageFile represents file information
 type StorageFile struct {
 	Key          string  `json:"key"`
@@ -87,6 +124,10 @@ type StorageFile struct {
 	LastModified string  `json:"last_modified"`
 	ContentType  *string `json:"content_type,omitempty"`
 	ETag         *string `json:"etag,omitempty"`
+	// Metadata holds the custom metadata attached to the object.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Tags holds the tags attached to the object.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 // FileUploadResult represents file upload result
@@ -95,5 +136,86 @@ type FileUploadResult struct {
 	Size    int64  `json:"size"`
 	URL     string `json:"url"`
 	Success bool   `json:"success"`
+	// ContentType is the stored content type, or the one the file was sent
+	// with if the server does not report it.
+	ContentType string `json:"content_type,omitempty"`
+	// SignedURL is a presigned download URL, set by UploadAndSign.
+	SignedURL string `json:"-"`
+	// ETag is the object's entity tag, usually its hex MD5.
+	ETag string `json:"etag,omitempty"`
+	// Checksum is the hex SHA-256 of the object, when the server computes it.
+	Checksum string `json:"checksum,omitempty"`
+	// IdempotencyKey is the Idempotency-Key the upload was sent with.
+	IdempotencyKey string `json:"-"`
+	// RemainingBytes is the storage left after the upload, based on the
+	// quota read by the upload check, or -1 if it is not known.
+	RemainingBytes int64 `json:"-"`
+}
+
+// DeleteResult reports the outcome of a batch delete per key
+type DeleteResult struct {
+	// Deleted lists the keys that were removed.
+	Deleted []string `json:"deleted"`
+	// Errors maps each key that could not be removed to the reason.
+	Errors map[string]string `json:"errors,omitempty"`
 }
 
+// OK reports whether every key was deleted.
+func (r *DeleteResult) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// FileURLInfo describes a presigned file URL and the file it points to
+type FileURLInfo struct {
+	URL         string `json:"url"`
+	Key         string `json:"key"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// PresignedRequest is a presigned URL together with how it must be called
+type PresignedRequest struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+	// Headers are part of the signature and must be sent with the request
+	// exactly as given.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// StorageInfo represents the S3 storage configuration of a project
+type StorageInfo struct {
+	BucketName  string `json:"bucket_name"`
+	Region      string `json:"region"`
+	Endpoint    string `json:"endpoint,omitempty"`
+	Status      string `json:"status,omitempty"`
+	ObjectCount int64  `json:"object_count,omitempty"`
+	SizeBytes   int64  `json:"size_bytes,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+// Region is an S3 region storage can be provisioned in, with its pricing
+type Region struct {
+	Code            string  `json:"code"`
+	DisplayName     string  `json:"display_name"`
+	PricePerGBMonth float64 `json:"price_per_gb_month"`
+	PricePerRequest float64 `json:"price_per_request"`
+	// Latency is the typical round-trip latency to the region in milliseconds.
+	Latency float64 `json:"latency"`
+}
+
+// ProvisionResult holds the storage bucket and credentials created by
+// ProvisionStorage. The secret is only returned once, so store it securely.
+type ProvisionResult struct {
+	Bucket          string `json:"bucket_name"`
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Endpoint        string `json:"endpoint,omitempty"`
+}
+
+// String describes the result with the secret redacted, so it is safe to log.
+func (r ProvisionResult) String() string {
+	return fmt.Sprintf("ProvisionResult{Bucket: %s, Region: %s, AccessKeyID: %s, SecretAccessKey: [REDACTED], Endpoint: %s}",
+		r.Bucket, r.Region, r.AccessKeyID, r.Endpoint)
+}
@@ -1,6 +1,7 @@
 package WOWSQL
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -152,9 +153,14 @@ func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
 
 // Execute executes the query and returns results
 func (qb *QueryBuilder) Execute() (*QueryResponse, error) {
+	return qb.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is like Execute but uses ctx for cancellation and deadlines.
+func (qb *QueryBuilder) ExecuteContext(ctx context.Context) (*QueryResponse, error) {
 	body := qb.buildQueryBody()
 
-	resp, err := qb.client.doRequest("POST", fmt.Sprintf("/api/v1/tables/%s/query", qb.tableName), body)
+	resp, err := qb.client.doRequest(ctx, "POST", fmt.Sprintf("/api/v1/tables/%s/query", qb.tableName), body)
 	if err != nil {
 		return nil, err
 	}
@@ -174,8 +180,13 @@ func (qb *QueryBuilder) Get() (*QueryResponse, error) {
 
 // First retrieves only the first result
 func (qb *QueryBuilder) First() (map[string]interface{}, error) {
+	return qb.FirstContext(context.Background())
+}
+
+// FirstContext is like First but uses ctx for cancellation and deadlines.
+func (qb *QueryBuilder) FirstContext(ctx context.Context) (map[string]interface{}, error) {
 	qb.Limit(1)
-	result, err := qb.Execute()
+	result, err := qb.ExecuteContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -189,6 +200,11 @@ func (qb *QueryBuilder) First() (map[string]interface{}, error) {
 
 // Update updates records matching the query
 func (qb *QueryBuilder) Update(data map[string]interface{}) (*UpdateResponse, error) {
+	return qb.UpdateContext(context.Background(), data)
+}
+
+// UpdateContext is like Update but uses ctx for cancellation and deadlines.
+func (qb *QueryBuilder) UpdateContext(ctx context.Context, data map[string]interface{}) (*UpdateResponse, error) {
 	body := map[string]interface{}{
 		"data": data,
 	}
@@ -197,7 +213,7 @@ func (qb *QueryBuilder) Update(data map[string]interface{}) (*UpdateResponse, er
 		body["filters"] = qb.filters
 	}
 
-	resp, err := qb.client.doRequest("PUT", fmt.Sprintf("/api/v1/tables/%s", qb.tableName), body)
+	resp, err := qb.client.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/tables/%s", qb.tableName), body)
 	if err != nil {
 		return nil, err
 	}
@@ -212,13 +228,18 @@ func (qb *QueryBuilder) Update(data map[string]interface{}) (*UpdateResponse, er
 
 // Delete deletes records matching the query
 func (qb *QueryBuilder) Delete() (*DeleteResponse, error) {
+	return qb.DeleteContext(context.Background())
+}
+
+// DeleteContext is like Delete but uses ctx for cancellation and deadlines.
+func (qb *QueryBuilder) DeleteContext(ctx context.Context) (*DeleteResponse, error) {
 	body := make(map[string]interface{})
 
 	if len(qb.filters) > 0 {
 		body["filters"] = qb.filters
 	}
 
-	resp, err := qb.client.doRequest("DELETE", fmt.Sprintf("/api/v1/tables/%s", qb.tableName), body)
+	resp, err := qb.client.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/tables/%s", qb.tableName), body)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,134 @@
+package WOWSQL
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how transient failures are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. Defaults to 30 seconds.
+	MaxDelay time.Duration
+	// RetryNonIdempotent allows POST/PATCH requests to be retried as well.
+	// Leave this off unless the server deduplicates those requests.
+	RetryNonIdempotent bool
+}
+
+// retrySafeKey marks a context whose request the caller has made safe to
+// repeat, e.g. by supplying its own idempotency key.
+type retrySafeKey struct{}
+
+// withRetrySafe returns a context that lets non-idempotent requests be
+// retried.
+func withRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+func isRetrySafe(ctx context.Context) bool {
+	safe, _ := ctx.Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// enabled reports whether the policy allows more than one attempt.
+func (p *RetryPolicy) enabled() bool {
+	return p != nil && p.MaxAttempts > 1
+}
+
+// allowsMethod reports whether requests with the given method may be retried.
+func (p *RetryPolicy) allowsMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return p.RetryNonIdempotent
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Full jitter in the upper half keeps concurrent clients from retrying in lockstep.
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatus reports whether a response status indicates a transient failure.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRetryableError reports whether err is a network failure or an API error
+// with a transient status code.
+func isRetryableError(err error) bool {
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var apiErr *WOWSQLError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.StatusCode)
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
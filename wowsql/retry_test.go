@@ -0,0 +1,124 @@
+package WOWSQL
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry int
+		full  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(tt.retry); got < tt.full/2 || got > tt.full {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.retry, got, tt.full/2, tt.full)
+			}
+		}
+	}
+
+	if got := (&RetryPolicy{MaxAttempts: 3}).backoff(1); got != 0 {
+		t.Errorf("backoff without BaseDelay = %v, want 0", got)
+	}
+}
+
+func TestRetryPolicyAllowsMethod(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3}
+	for method, want := range map[string]bool{"GET": true, "head": true, "OPTIONS": true, "POST": false, "PATCH": false, "DELETE": false} {
+		if got := policy.allowsMethod(method); got != want {
+			t.Errorf("allowsMethod(%q) = %v, want %v", method, got, want)
+		}
+	}
+	policy.RetryNonIdempotent = true
+	if !policy.allowsMethod("POST") {
+		t.Error("allowsMethod(POST) = false with RetryNonIdempotent")
+	}
+
+	var disabled *RetryPolicy
+	if disabled.enabled() || (&RetryPolicy{MaxAttempts: 1}).enabled() {
+		t.Error("a nil or single-attempt policy reports enabled")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		" 10 ":                          10 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Wed, 21 Oct 2015 07:28:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 55*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want about a minute", future, got)
+	}
+}
+
+func TestAuthClientRetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"user-1","email":"user@example.com"}`))
+	}))
+	defer server.Close()
+
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"}).WithRetry(3, time.Millisecond)
+	user, err := client.GetUser("token")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.ID != "user-1" || calls.Load() != 3 {
+		t.Errorf("got user %q after %d calls, want user-1 after 3", user.ID, calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := client.SignIn("user@example.com", "password"); err == nil {
+		t.Fatal("SignIn succeeded, want the 503 error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("SignIn was sent %d times, want 1", calls.Load())
+	}
+}
+
+func TestAuthClientRetriesSignUpOnlyWithCallerKey(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	client := NewAuthClient(AuthConfig{ProjectURL: server.URL, APIKey: "wowsql_anon_test"}).WithRetry(3, time.Millisecond)
+	client.SignUp("user@example.com", "password123")
+	if calls.Load() != 1 {
+		t.Errorf("SignUp with a generated key was sent %d times, want 1", calls.Load())
+	}
+
+	calls.Store(0)
+	client.SignUp("user@example.com", "password123", WithSignUpIdempotencyKey(NewIdempotencyKey()))
+	if calls.Load() != 3 {
+		t.Errorf("SignUp with a caller key was sent %d times, want 3", calls.Load())
+	}
+}
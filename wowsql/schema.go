@@ -2,225 +2,952 @@ package WOWSQL
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
-// ColumnDefinition represents a column in a table
+// ColumnDefinition represents a column definition for table creation.
+// Optional fields are pointers so that false and "" can be sent explicitly;
+// use BoolPtr and StringPtr to set them.
 type ColumnDefinition struct {
-	Name          string `json:"name"`
-	Type          string `json:"type"`
-	AutoIncrement bool   `json:"auto_increment,omitempty"`
-	Unique        bool   `json:"unique,omitempty"`
-	Nullable      bool   `json:"nullable,omitempty"`
-	Default       string `json:"default,omitempty"`
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	AutoIncrement *bool   `json:"auto_increment,omitempty"`
+	Unique        *bool   `json:"unique,omitempty"`
+	Nullable      *bool   `json:"nullable,omitempty"`
+	Default       *string `json:"default,omitempty"`
+	// Check is a boolean expression every row must satisfy, e.g. "price >= 0".
+	Check *string `json:"check,omitempty"`
+	// Comment documents the column. It is sent as data and quoted by the
+	// server, so it may contain quotes.
+	Comment *string `json:"comment,omitempty"`
+	// ForeignKey makes the column reference another table's column.
+	ForeignKey *ForeignKeyDefinition `json:"foreign_key,omitempty"`
 }
 
-// CreateTableOptions contains options for creating a table
-type CreateTableOptions struct {
+// MaxColumnCommentLength is the longest column comment the database accepts.
+const MaxColumnCommentLength = 1024
+
+// validate checks the column's CHECK constraint, comment and foreign key.
+func (col *ColumnDefinition) validate() error {
+	if err := validateColumnExtras(col.Check, col.Comment); err != nil {
+		return err
+	}
+	if col.ForeignKey != nil {
+		return col.ForeignKey.validate()
+	}
+	return nil
+}
+
+// validateColumnExtras rejects an empty CHECK expression, one that could
+// close the constraint early or start another statement, and comments the
+// database cannot store.
+func validateColumnExtras(check, comment *string) error {
+	if check != nil {
+		expr := strings.TrimSpace(*check)
+		if expr == "" {
+			return fmt.Errorf("check expression must not be empty")
+		}
+		if strings.Contains(expr, ";") {
+			return fmt.Errorf("check expression must not contain ';'")
+		}
+		depth := 0
+		for _, r := range expr {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth < 0 {
+				break
+			}
+		}
+		if depth != 0 {
+			return fmt.Errorf("check expression has unbalanced parentheses")
+		}
+	}
+	if comment != nil {
+		if strings.ContainsRune(*comment, 0) {
+			return fmt.Errorf("comment must not contain NUL characters")
+		}
+		if utf8.RuneCountInString(*comment) > MaxColumnCommentLength {
+			return fmt.Errorf("comment must be at most %d characters", MaxColumnCommentLength)
+		}
+	}
+	return nil
+}
+
+// Referential actions accepted by ForeignKeyDefinition.OnDelete and OnUpdate
+const (
+	ReferentialActionCascade  = "CASCADE"
+	ReferentialActionSetNull  = "SET NULL"
+	ReferentialActionRestrict = "RESTRICT"
+	ReferentialActionNoAction = "NO ACTION"
+)
+
+// ForeignKeyDefinition describes a foreign key constraint. Column may be left
+// empty when the definition is attached to a ColumnDefinition.
+type ForeignKeyDefinition struct {
+	Name             string `json:"name,omitempty"`
+	Column           string `json:"column,omitempty"`
+	ReferencesTable  string `json:"references_table"`
+	ReferencesColumn string `json:"references_column"`
+	OnDelete         string `json:"on_delete,omitempty"`
+	OnUpdate         string `json:"on_update,omitempty"`
+}
+
+// validate checks the referenced column and referential actions.
+func (fk *ForeignKeyDefinition) validate() error {
+	if fk.ReferencesTable == "" || fk.ReferencesColumn == "" {
+		return fmt.Errorf("foreign key must reference a table and column")
+	}
+	for _, action := range []string{fk.OnDelete, fk.OnUpdate} {
+		switch strings.ToUpper(action) {
+		case "", ReferentialActionCascade, ReferentialActionSetNull, ReferentialActionRestrict, ReferentialActionNoAction:
+		default:
+			return fmt.Errorf("invalid referential action %q: must be CASCADE, SET NULL, RESTRICT or NO ACTION", action)
+		}
+	}
+	return nil
+}
+
+// CreateTableRequest represents a request to create a table
+type CreateTableRequest struct {
 	TableName  string             `json:"table_name"`
 	Columns    []ColumnDefinition `json:"columns"`
-	PrimaryKey string             `json:"primary_key,omitempty"`
+	PrimaryKey *string            `json:"primary_key,omitempty"`
 	Indexes    []string           `json:"indexes,omitempty"`
 }
 
-// AlterTableOptions contains options for altering a table
-type AlterTableOptions struct {
-	TableName     string `json:"table_name"`
-	Operation     string `json:"operation"` // add_column, drop_column, modify_column, rename_column
-	ColumnName    string `json:"column_name,omitempty"`
-	ColumnType    string `json:"column_type,omitempty"`
-	NewColumnName string `json:"new_column_name,omitempty"`
-	Nullable      *bool  `json:"nullable,omitempty"`
-	Default       string `json:"default,omitempty"`
+// Operations accepted by AlterTableRequest.Operation
+const (
+	AlterOperationAddColumn    = "add_column"
+	AlterOperationDropColumn   = "drop_column"
+	AlterOperationModifyColumn = "modify_column"
+	AlterOperationRenameColumn = "rename_column"
+)
+
+// AlterTableRequest represents a request to alter a table
+type AlterTableRequest struct {
+	TableName     string  `json:"table_name"`
+	Operation     string  `json:"operation"` // add_column, drop_column, modify_column, rename_column
+	ColumnName    *string `json:"column_name,omitempty"`
+	ColumnType    *string `json:"column_type,omitempty"`
+	NewColumnName *string `json:"new_column_name,omitempty"`
+	Nullable      *bool   `json:"nullable,omitempty"`
+	Default       *string `json:"default,omitempty"`
+	Check         *string `json:"check,omitempty"`
+	Comment       *string `json:"comment,omitempty"`
+}
+
+// Index types accepted by IndexOptions.Type
+const (
+	IndexTypeBTree    = "btree"
+	IndexTypeHash     = "hash"
+	IndexTypeFulltext = "fulltext"
+)
+
+// IndexOptions describes an index to create
+type IndexOptions struct {
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+	Type    string   `json:"type,omitempty"` // btree, hash, fulltext
+}
+
+// CreateTableOptions is the former name of CreateTableRequest.
+//
+// Deprecated: Use CreateTableRequest.
+type CreateTableOptions = CreateTableRequest
+
+// AlterTableOptions is the former name of AlterTableRequest.
+//
+// Deprecated: Use AlterTableRequest.
+type AlterTableOptions = AlterTableRequest
+
+// SchemaResponse represents a schema operation response
+type SchemaResponse struct {
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	Table        string `json:"table,omitempty"`
+	Operation    string `json:"operation,omitempty"`
+	RowsAffected int    `json:"rows_affected,omitempty"`
+	Warning      string `json:"warning,omitempty"`
+	// DryRun is true when the operation was only simulated; see WithDryRun.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Impact describes what the operation affects. It is set for dry runs.
+	Impact *OperationImpact `json:"impact,omitempty"`
+}
+
+// OperationImpact describes what a destructive operation would affect
+type OperationImpact struct {
+	// Rows is the number of rows that would be deleted.
+	Rows int64 `json:"rows"`
+	// DependentObjects lists views, foreign keys and other objects that
+	// would be dropped or broken, e.g. "view:active_users".
+	DependentObjects []string `json:"dependent_objects,omitempty"`
 }
 
-// SchemaClient handles schema operations
+// DestructiveOption configures DropTable and TruncateTable.
+type DestructiveOption func(*destructiveOptions)
+
+type destructiveOptions struct {
+	dryRun bool
+}
+
+// WithDryRun makes DropTable or TruncateTable report what would be affected
+// in SchemaResponse.Impact without changing anything, e.g. to show a
+// confirmation prompt first. It is sent as the dry_run query parameter.
+func WithDryRun() DestructiveOption {
+	return func(o *destructiveOptions) {
+		o.dryRun = true
+	}
+}
+
+func applyDestructiveOptions(opts []DestructiveOption) destructiveOptions {
+	var options destructiveOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// QueryResult holds the outcome of ExecuteQuery. For statements that return
+// rows, Columns and Rows are set and each row lists values in column order;
+// otherwise only RowsAffected is set.
+type QueryResult struct {
+	Columns      []string
+	Rows         [][]interface{}
+	RowsAffected int
+}
+
+// HasRows reports whether the statement returned a result set.
+func (r *QueryResult) HasRows() bool {
+	return r.Columns != nil
+}
+
+// DefaultSchemaTimeout is the request timeout used by NewSchemaClient.
+// Long-running DDL can use NewSchemaClientWithTimeout or a context deadline.
+const DefaultSchemaTimeout = 60 * time.Second
+
+// SchemaClient handles schema management operations
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+// Calls made with any other key fail with an error matching
+// ErrServiceKeyRequired.
 type SchemaClient struct {
 	baseURL    string
-	serviceKey string
+	basePath   string // empty for DefaultSchemaBasePath
 	httpClient *http.Client
+
+	interceptors []RequestInterceptor
+
+	mu         sync.RWMutex // guards serviceKey
+	serviceKey string
 }
 
-// NewSchemaClient creates a new schema client
+// NewSchemaClient creates a new schema management client
+//
 // ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
 func NewSchemaClient(projectURL, serviceKey string) *SchemaClient {
+	return NewSchemaClientWithTimeout(projectURL, serviceKey, DefaultSchemaTimeout)
+}
+
+// NewSchemaClientWithTimeout creates a new schema management client with a custom timeout
+//
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func NewSchemaClientWithTimeout(projectURL, serviceKey string, timeout time.Duration) *SchemaClient {
+	return NewSchemaClientWithHTTPClient(projectURL, serviceKey, &http.Client{
+		Timeout: timeout,
+	})
+}
+
+// NewSchemaClientWithHTTPClient creates a new schema management client that
+// sends requests through httpClient, e.g. to share a transport or proxy.
+// projectURL may be a full URL or just the project slug, which resolves to
+// https://<slug>.wowsql.com; use NewSchemaClientWithConfig for other domains.
+//
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func NewSchemaClientWithHTTPClient(projectURL, serviceKey string, httpClient *http.Client) *SchemaClient {
+	return NewSchemaClientWithConfig(SchemaConfig{
+		ProjectURL: projectURL,
+		Secure:     true,
+		ServiceKey: serviceKey,
+		HTTPClient: httpClient,
+	})
+}
+
+// SchemaConfig configures a schema client created with
+// NewSchemaClientWithConfig. ProjectURL, BaseDomain and Secure are resolved
+// like the fields of AuthConfig.
+type SchemaConfig struct {
+	ProjectURL string
+	BaseDomain string
+	Secure     bool
+	// ServiceKey must be a SERVICE ROLE key.
+	ServiceKey string
+	// Timeout is the request timeout. Defaults to DefaultSchemaTimeout.
+	// Ignored if HTTPClient is set.
+	Timeout time.Duration
+	// HTTPClient sends the requests, e.g. to share a transport or proxy.
+	HTTPClient *http.Client
+}
+
+// NewSchemaClientWithConfig creates a new schema management client from config.
+//
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func NewSchemaClientWithConfig(config SchemaConfig) *SchemaClient {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		timeout := config.Timeout
+		if timeout == 0 {
+			timeout = DefaultSchemaTimeout
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
 	return &SchemaClient{
-		baseURL:    projectURL,
-		serviceKey: serviceKey,
-		httpClient: &http.Client{},
+		baseURL:    ResolveProjectURL(config.ProjectURL, config.BaseDomain, config.Secure),
+		serviceKey: config.ServiceKey,
+		httpClient: httpClient,
 	}
 }
 
-// CreateTable creates a new table
-func (s *SchemaClient) CreateTable(options CreateTableOptions) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v2/schema/tables", s.baseURL)
+// WithRequestInterceptor adds fn to the interceptors run on every request
+// before it is sent, in the order they were added. Call it before the client
+// is shared between goroutines.
+func (c *SchemaClient) WithRequestInterceptor(fn RequestInterceptor) *SchemaClient {
+	c.interceptors = append(c.interceptors, fn)
+	return c
+}
 
-	jsonData, err := json.Marshal(options)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// SetTimeout sets the timeout for each request. Calls made with a context
+// that has a deadline use that deadline instead. Call it before the client is
+// shared between goroutines.
+func (c *SchemaClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// SetBasePath sets the path the schema API is mounted under, replacing
+// DefaultSchemaBasePath, e.g. to pin another API version. An empty basePath
+// restores the default. Call it before the client is shared between
+// goroutines.
+func (c *SchemaClient) SetBasePath(basePath string) {
+	c.basePath = normalizeBasePath(basePath)
+}
+
+// SetAPIKey replaces the service key sent with every request, e.g. when keys
+// are rotated. It is safe to call while other goroutines use the client.
+func (c *SchemaClient) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serviceKey = key
+}
+
+// CreateTable creates a new table in the database
+//
+// Example:
+//
+//	_, err := schema.CreateTable(WOWSQL.CreateTableRequest{
+//	    TableName: "users",
+//	    Columns: []WOWSQL.ColumnDefinition{
+//	        {Name: "id", Type: "INT", AutoIncrement: WOWSQL.BoolPtr(true)},
+//	        {Name: "email", Type: "VARCHAR(255)", Unique: WOWSQL.BoolPtr(true), Nullable: WOWSQL.BoolPtr(false)},
+//	    },
+//	    PrimaryKey: WOWSQL.StringPtr("id"),
+//	    Indexes: []string{"email"},
+//	})
+func (c *SchemaClient) CreateTable(req CreateTableRequest) (*SchemaResponse, error) {
+	return c.CreateTableContext(context.Background(), req)
+}
+
+// CreateTableContext is like CreateTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) CreateTableContext(ctx context.Context, req CreateTableRequest) (*SchemaResponse, error) {
+	for _, column := range req.Columns {
+		if err := column.validate(); err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.Name, err)
+		}
 	}
+	return c.doRequest(ctx, "POST", "/api/v2/schema/tables", req, "create table")
+}
 
-	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
-	req.Header.Set("Content-Type", "application/json")
+// AlterTable alters an existing table
+//
+// Example:
+//
+//	_, err := schema.AlterTable(WOWSQL.AlterTableRequest{
+//	    TableName: "users",
+//	    Operation: "add_column",
+//	    ColumnName: WOWSQL.StringPtr("phone"),
+//	    ColumnType: WOWSQL.StringPtr("VARCHAR(20)"),
+//	})
+func (c *SchemaClient) AlterTable(req AlterTableRequest) (*SchemaResponse, error) {
+	return c.AlterTableContext(context.Background(), req)
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+// AlterTableContext is like AlterTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) AlterTableContext(ctx context.Context, req AlterTableRequest) (*SchemaResponse, error) {
+	switch req.Operation {
+	case AlterOperationAddColumn, AlterOperationDropColumn, AlterOperationModifyColumn, AlterOperationRenameColumn:
+	default:
+		return nil, fmt.Errorf("invalid alter operation %q", req.Operation)
 	}
-	defer resp.Body.Close()
+	if err := validateColumnExtras(req.Check, req.Comment); err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/api/v2/schema/tables/%s", url.PathEscape(req.TableName))
+	return c.doRequest(ctx, "PATCH", path, req, "alter table")
+}
+
+// AddColumn adds a column to a table
+func (c *SchemaClient) AddColumn(tableName string, column ColumnDefinition) (*SchemaResponse, error) {
+	return c.AddColumnContext(context.Background(), tableName, column)
+}
+
+// AddColumnContext is like AddColumn but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) AddColumnContext(ctx context.Context, tableName string, column ColumnDefinition) (*SchemaResponse, error) {
+	return c.AlterTableContext(ctx, AlterTableRequest{
+		TableName:  tableName,
+		Operation:  AlterOperationAddColumn,
+		ColumnName: &column.Name,
+		ColumnType: &column.Type,
+		Nullable:   column.Nullable,
+		Default:    column.Default,
+		Check:      column.Check,
+		Comment:    column.Comment,
+	})
+}
+
+// DropColumn drops a column from a table
+//
+// ⚠️ WARNING: This operation cannot be undone!
+func (c *SchemaClient) DropColumn(tableName, columnName string) (*SchemaResponse, error) {
+	return c.DropColumnContext(context.Background(), tableName, columnName)
+}
 
-	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("schema operations require a SERVICE ROLE key. You are using an anonymous key which cannot modify database schema")
+// DropColumnContext is like DropColumn but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) DropColumnContext(ctx context.Context, tableName, columnName string) (*SchemaResponse, error) {
+	return c.AlterTableContext(ctx, AlterTableRequest{
+		TableName:  tableName,
+		Operation:  AlterOperationDropColumn,
+		ColumnName: &columnName,
+	})
+}
+
+// RenameColumn renames a column of a table
+func (c *SchemaClient) RenameColumn(tableName, oldName, newName string) (*SchemaResponse, error) {
+	return c.RenameColumnContext(context.Background(), tableName, oldName, newName)
+}
+
+// RenameColumnContext is like RenameColumn but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) RenameColumnContext(ctx context.Context, tableName, oldName, newName string) (*SchemaResponse, error) {
+	return c.AlterTableContext(ctx, AlterTableRequest{
+		TableName:     tableName,
+		Operation:     AlterOperationRenameColumn,
+		ColumnName:    &oldName,
+		NewColumnName: &newName,
+	})
+}
+
+// ModifyColumn changes the type of a column
+func (c *SchemaClient) ModifyColumn(tableName, columnName, newType string) (*SchemaResponse, error) {
+	return c.ModifyColumnContext(context.Background(), tableName, columnName, newType)
+}
+
+// ModifyColumnContext is like ModifyColumn but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) ModifyColumnContext(ctx context.Context, tableName, columnName, newType string) (*SchemaResponse, error) {
+	return c.AlterTableContext(ctx, AlterTableRequest{
+		TableName:  tableName,
+		Operation:  AlterOperationModifyColumn,
+		ColumnName: &columnName,
+		ColumnType: &newType,
+	})
+}
+
+// DropTable drops a table from the database. Pass WithDryRun to only report
+// the rows and dependent objects that would be lost.
+//
+// ⚠️ WARNING: This operation cannot be undone!
+func (c *SchemaClient) DropTable(tableName string, cascade bool, opts ...DestructiveOption) (*SchemaResponse, error) {
+	return c.DropTableContext(context.Background(), tableName, cascade, opts...)
+}
+
+// DropTableContext is like DropTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) DropTableContext(ctx context.Context, tableName string, cascade bool, opts ...DestructiveOption) (*SchemaResponse, error) {
+	path := fmt.Sprintf("/api/v2/schema/tables/%s?cascade=%t", url.PathEscape(tableName), cascade)
+	if applyDestructiveOptions(opts).dryRun {
+		path += "&dry_run=true"
 	}
+	return c.doRequest(ctx, "DELETE", path, nil, "drop table")
+}
 
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		var errorResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		if detail, ok := errorResp["detail"].(string); ok {
-			return nil, fmt.Errorf("failed to create table: %s", detail)
-		}
-		return nil, fmt.Errorf("failed to create table: status %d", resp.StatusCode)
+// RenameTable renames a table
+func (c *SchemaClient) RenameTable(oldName, newName string) (*SchemaResponse, error) {
+	return c.RenameTableContext(context.Background(), oldName, newName)
+}
+
+// RenameTableContext is like RenameTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) RenameTableContext(ctx context.Context, oldName, newName string) (*SchemaResponse, error) {
+	body := map[string]string{"new_name": newName}
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/rename", url.PathEscape(oldName))
+	return c.doRequest(ctx, "POST", path, body, "rename table")
+}
+
+// TruncateTable deletes every row of a table. With restartIdentity,
+// auto-increment counters are reset as well. Pass WithDryRun to only report
+// how many rows would be deleted.
+//
+// ⚠️ WARNING: This operation cannot be undone!
+func (c *SchemaClient) TruncateTable(tableName string, restartIdentity bool, opts ...DestructiveOption) (*SchemaResponse, error) {
+	return c.TruncateTableContext(context.Background(), tableName, restartIdentity, opts...)
+}
+
+// TruncateTableContext is like TruncateTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) TruncateTableContext(ctx context.Context, tableName string, restartIdentity bool, opts ...DestructiveOption) (*SchemaResponse, error) {
+	body := map[string]bool{"restart_identity": restartIdentity}
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/truncate", url.PathEscape(tableName))
+	if applyDestructiveOptions(opts).dryRun {
+		path += "?dry_run=true"
 	}
+	return c.doRequest(ctx, "POST", path, body, "truncate table")
+}
+
+// CreateIndex creates an index on one or more columns of a table
+//
+// Example:
+//
+//	_, err := schema.CreateIndex("orders", WOWSQL.IndexOptions{
+//	    Name:    "idx_orders_user_created",
+//	    Columns: []string{"user_id", "created_at"},
+//	})
+func (c *SchemaClient) CreateIndex(tableName string, opts IndexOptions) (*SchemaResponse, error) {
+	return c.CreateIndexContext(context.Background(), tableName, opts)
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// CreateIndexContext is like CreateIndex but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) CreateIndexContext(ctx context.Context, tableName string, opts IndexOptions) (*SchemaResponse, error) {
+	if len(opts.Columns) == 0 {
+		return nil, fmt.Errorf("at least one index column is required")
+	}
+	switch opts.Type {
+	case "", IndexTypeBTree, IndexTypeHash, IndexTypeFulltext:
+	default:
+		return nil, fmt.Errorf("unsupported index type %q", opts.Type)
 	}
 
-	return result, nil
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/indexes", url.PathEscape(tableName))
+	return c.doRequest(ctx, "POST", path, opts, "create index")
 }
 
-// AlterTable modifies an existing table
-func (s *SchemaClient) AlterTable(options AlterTableOptions) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v2/schema/tables/%s", s.baseURL, options.TableName)
+// DropIndex drops an index from a table
+func (c *SchemaClient) DropIndex(tableName, indexName string) (*SchemaResponse, error) {
+	return c.DropIndexContext(context.Background(), tableName, indexName)
+}
 
-	jsonData, err := json.Marshal(options)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// DropIndexContext is like DropIndex but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) DropIndexContext(ctx context.Context, tableName, indexName string) (*SchemaResponse, error) {
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/indexes/%s", url.PathEscape(tableName), url.PathEscape(indexName))
+	return c.doRequest(ctx, "DELETE", path, nil, "drop index")
+}
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// AddForeignKey adds a foreign key constraint on fk.Column of a table
+//
+// Example:
+//
+//	_, err := schema.AddForeignKey("orders", WOWSQL.ForeignKeyDefinition{
+//	    Column:           "user_id",
+//	    ReferencesTable:  "users",
+//	    ReferencesColumn: "id",
+//	    OnDelete:         WOWSQL.ReferentialActionCascade,
+//	})
+func (c *SchemaClient) AddForeignKey(tableName string, fk ForeignKeyDefinition) (*SchemaResponse, error) {
+	return c.AddForeignKeyContext(context.Background(), tableName, fk)
+}
+
+// AddForeignKeyContext is like AddForeignKey but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) AddForeignKeyContext(ctx context.Context, tableName string, fk ForeignKeyDefinition) (*SchemaResponse, error) {
+	if fk.Column == "" {
+		return nil, fmt.Errorf("foreign key column is required")
+	}
+	if err := fk.validate(); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
-	req.Header.Set("Content-Type", "application/json")
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/foreign-keys", url.PathEscape(tableName))
+	return c.doRequest(ctx, "POST", path, fk, "add foreign key")
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+// DropForeignKey drops a foreign key constraint by name
+func (c *SchemaClient) DropForeignKey(tableName, constraintName string) (*SchemaResponse, error) {
+	return c.DropForeignKeyContext(context.Background(), tableName, constraintName)
+}
+
+// DropForeignKeyContext is like DropForeignKey but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) DropForeignKeyContext(ctx context.Context, tableName, constraintName string) (*SchemaResponse, error) {
+	path := fmt.Sprintf("/api/v2/schema/tables/%s/foreign-keys/%s", url.PathEscape(tableName), url.PathEscape(constraintName))
+	return c.doRequest(ctx, "DELETE", path, nil, "drop foreign key")
+}
+
+// ListTables lists the tables in the database
+func (c *SchemaClient) ListTables() ([]string, error) {
+	return c.ListTablesContext(context.Background())
+}
+
+// ListTablesContext is like ListTables but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) ListTablesContext(ctx context.Context) ([]string, error) {
+	var result struct {
+		Tables []string `json:"tables"`
 	}
-	defer resp.Body.Close()
+	if err := c.do(ctx, "GET", "/api/v2/schema/tables", nil, "list tables", &result); err != nil {
+		return nil, err
+	}
+	return result.Tables, nil
+}
 
-	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("schema operations require a SERVICE ROLE key")
+// DescribeTable returns the columns, primary key and indexes of a table.
+// If the table does not exist the error matches ErrNotFound and *NotFoundError.
+func (c *SchemaClient) DescribeTable(tableName string) (*TableSchema, error) {
+	return c.DescribeTableContext(context.Background(), tableName)
+}
+
+// DescribeTableContext is like DescribeTable but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) DescribeTableContext(ctx context.Context, tableName string) (*TableSchema, error) {
+	var schema TableSchema
+	path := fmt.Sprintf("/api/v2/schema/tables/%s", url.PathEscape(tableName))
+	if err := c.do(ctx, "GET", path, nil, "describe table", &schema); err != nil {
+		return nil, err
 	}
+	return &schema, nil
+}
 
-	if resp.StatusCode != 200 {
-		var errorResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		if detail, ok := errorResp["detail"].(string); ok {
-			return nil, fmt.Errorf("failed to alter table: %s", detail)
+// Ping checks that the project is reachable and the service key is accepted
+// by listing tables. The error says whether the key was rejected or the host
+// is unreachable.
+func (c *SchemaClient) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) PingContext(ctx context.Context) error {
+	_, err := c.ListTablesContext(ctx)
+	return pingError(err)
+}
+
+// TableExists reports whether a table with the given name exists. A missing
+// table is not an error.
+func (c *SchemaClient) TableExists(tableName string) (bool, error) {
+	return c.TableExistsContext(context.Background(), tableName)
+}
+
+// TableExistsContext is like TableExists but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) TableExistsContext(ctx context.Context, tableName string) (bool, error) {
+	tables, err := c.ListTablesContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, table := range tables {
+		if table == tableName {
+			return true, nil
 		}
-		return nil, fmt.Errorf("failed to alter table: status %d", resp.StatusCode)
 	}
+	return false, nil
+}
+
+// CreateTableIfNotExists creates the table unless one with the same name
+// already exists, and reports whether it was created. An existing table is
+// left as is, even if its columns differ from req.
+func (c *SchemaClient) CreateTableIfNotExists(req CreateTableRequest) (bool, error) {
+	return c.CreateTableIfNotExistsContext(context.Background(), req)
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// CreateTableIfNotExistsContext is like CreateTableIfNotExists but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) CreateTableIfNotExistsContext(ctx context.Context, req CreateTableRequest) (bool, error) {
+	exists, err := c.TableExistsContext(ctx, req.TableName)
+	if err != nil || exists {
+		return false, err
 	}
+	if _, err := c.CreateTableContext(ctx, req); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	return result, nil
+// ExecuteSQL executes raw SQL for schema operations
+//
+// Example:
+//
+//	_, err := schema.ExecuteSQL(`
+//	    CREATE TABLE products (
+//	        id INT PRIMARY KEY AUTO_INCREMENT,
+//	        name VARCHAR(255) NOT NULL
+//	    )
+//	`)
+func (c *SchemaClient) ExecuteSQL(sql string) (*SchemaResponse, error) {
+	return c.ExecuteSQLContext(context.Background(), sql)
 }
 
-// DropTable deletes a table
-// ⚠️ WARNING: This operation cannot be undone!
-func (s *SchemaClient) DropTable(tableName string, cascade bool) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v2/schema/tables/%s?cascade=%t", s.baseURL, tableName, cascade)
+// ExecuteSQLContext is like ExecuteSQL but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) ExecuteSQLContext(ctx context.Context, sql string) (*SchemaResponse, error) {
+	return c.doRequest(ctx, "POST", "/api/v2/schema/execute", map[string]string{"sql": sql}, "execute SQL")
+}
 
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// ExecuteQuery executes SQL with optional positional args and returns its
+// result rows, e.g. for SELECT COUNT(*) or data validation queries.
+func (c *SchemaClient) ExecuteQuery(sql string, args ...interface{}) (*QueryResult, error) {
+	return c.ExecuteQueryContext(context.Background(), sql, args...)
+}
 
-	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+// ExecuteQueryContext is like ExecuteQuery but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) ExecuteQueryContext(ctx context.Context, sql string, args ...interface{}) (*QueryResult, error) {
+	body := map[string]interface{}{"sql": sql}
+	if len(args) > 0 {
+		body["params"] = args
+	}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	var raw struct {
+		Columns      []string          `json:"columns"`
+		Rows         []json.RawMessage `json:"rows"`
+		Data         []json.RawMessage `json:"data"`
+		RowsAffected int               `json:"rows_affected"`
+	}
+	if err := c.do(ctx, "POST", "/api/v2/schema/execute", body, "execute query", &raw); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("schema operations require a SERVICE ROLE key")
+	result := &QueryResult{Columns: raw.Columns, RowsAffected: raw.RowsAffected}
+	var objects []map[string]interface{}
+	for _, row := range append(raw.Rows, raw.Data...) {
+		// Rows may come back either as arrays or as column-keyed objects.
+		var values []interface{}
+		if err := json.Unmarshal(row, &values); err == nil {
+			result.Rows = append(result.Rows, values)
+			continue
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(row, &object); err != nil {
+			return nil, fmt.Errorf("failed to decode row: %w", err)
+		}
+		if len(objects) == 0 && result.Columns == nil {
+			// Go maps are unordered, so take the columns in the order the
+			// server sent them from the first row.
+			columns, err := objectKeys(row)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode row: %w", err)
+			}
+			result.Columns = columns
+		}
+		objects = append(objects, object)
 	}
 
-	if resp.StatusCode != 200 {
-		var errorResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		if detail, ok := errorResp["detail"].(string); ok {
-			return nil, fmt.Errorf("failed to drop table: %s", detail)
+	for _, object := range objects {
+		values := make([]interface{}, len(result.Columns))
+		for i, column := range result.Columns {
+			values[i] = object[column]
 		}
-		return nil, fmt.Errorf("failed to drop table: status %d", resp.StatusCode)
+		result.Rows = append(result.Rows, values)
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if result.Columns == nil && (raw.Rows != nil || raw.Data != nil) {
+		// An empty result set still has rows, just none of them.
+		result.Columns = []string{}
+	}
+	if result.HasRows() && result.Rows == nil {
+		result.Rows = [][]interface{}{}
 	}
 
 	return result, nil
 }
 
-// ExecuteSQL executes raw SQL for schema operations
-func (s *SchemaClient) ExecuteSQL(sql string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v2/schema/execute", s.baseURL)
+// objectKeys returns the keys of a JSON object in the order they appear.
+func objectKeys(data json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
 
-	payload := map[string]string{"sql": sql}
-	jsonData, err := json.Marshal(payload)
+// ExecuteBatch runs statements in order in a single server-side transaction.
+// If any statement fails, all of them are rolled back and the error is a
+// *BatchStatementError naming the failed statement, when the server reports
+// it.
+//
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, not anonymous key!
+func (c *SchemaClient) ExecuteBatch(statements []string) (*SchemaResponse, error) {
+	return c.ExecuteBatchContext(context.Background(), statements)
+}
+
+// ExecuteBatchContext is like ExecuteBatch but uses ctx for cancellation and deadlines.
+func (c *SchemaClient) ExecuteBatchContext(ctx context.Context, statements []string) (*SchemaResponse, error) {
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("at least one statement is required")
+	}
+	for i, statement := range statements {
+		if strings.TrimSpace(statement) == "" {
+			return nil, fmt.Errorf("statement %d is empty", i)
+		}
+	}
+
+	body := map[string]interface{}{
+		"statements":  statements,
+		"transaction": true,
+	}
+	resp, err := c.doRequest(ctx, "POST", "/api/v2/schema/execute-batch", body, "execute batch")
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		var apiErr *WOWSQLError
+		if errors.As(err, &apiErr) {
+			for _, field := range []string{"failed_index", "statement_index"} {
+				if index, ok := apiErr.Response[field].(float64); ok && int(index) >= 0 && int(index) < len(statements) {
+					return nil, &BatchStatementError{Index: int(index), Statement: statements[int(index)], Err: err}
+				}
+			}
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// doRequest sends a schema request and decodes the SchemaResponse. action
+// describes the operation in error messages.
+func (c *SchemaClient) doRequest(ctx context.Context, method, path string, body interface{}, action string) (*SchemaResponse, error) {
+	var result SchemaResponse
+	if err := c.do(ctx, method, path, body, action, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// do sends a schema request and decodes the response into out.
+func (c *SchemaClient) do(ctx context.Context, method, path string, body interface{}, action string, out interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	path = rebase(path, DefaultSchemaBasePath, c.basePath)
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
-	req.Header.Set("Content-Type", "application/json")
+	c.mu.RLock()
+	serviceKey := c.serviceKey
+	c.mu.RUnlock()
+	httpReq.Header.Set("Authorization", "Bearer "+serviceKey)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if err := intercept(c.interceptors, httpReq); err != nil {
+		return err
+	}
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := httpClientFor(ctx, c.httpClient).Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("schema operations require a SERVICE ROLE key")
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		respBody, _ := io.ReadAll(resp.Body)
+		err := withRetryAfter(parseError(resp.StatusCode, respBody), resp.Header)
+		if resp.StatusCode == 403 {
+			// Keep the server's message; the sentinel carries the hint.
+			return fmt.Errorf("failed to %s: %w: %w", action, ErrServiceKeyRequired, err)
+		}
+		return fmt.Errorf("failed to %s: %w", action, err)
 	}
 
-	if resp.StatusCode != 200 {
-		var errorResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorResp)
-		if detail, ok := errorResp["detail"].(string); ok {
-			return nil, fmt.Errorf("failed to execute SQL: %s", detail)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// NewEnumColumn returns a column of type ENUM('a', 'b', ...) holding one of
+// values. Values are quoted and escaped; they must be non-empty and unique.
+func NewEnumColumn(name string, values []string) (ColumnDefinition, error) {
+	return newValueListColumn(name, "ENUM", values)
+}
+
+// NewSetColumn is like NewEnumColumn but builds a SET column, which holds any
+// combination of values. SET values must not contain commas.
+func NewSetColumn(name string, values []string) (ColumnDefinition, error) {
+	for _, value := range values {
+		if strings.Contains(value, ",") {
+			return ColumnDefinition{}, fmt.Errorf("SET value %q must not contain ','", value)
 		}
-		return nil, fmt.Errorf("failed to execute SQL: status %d", resp.StatusCode)
 	}
+	return newValueListColumn(name, "SET", values)
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+func newValueListColumn(name, kind string, values []string) (ColumnDefinition, error) {
+	if name == "" {
+		return ColumnDefinition{}, fmt.Errorf("column name is required")
+	}
+	if len(values) == 0 {
+		return ColumnDefinition{}, fmt.Errorf("%s column %s needs at least one value", kind, name)
 	}
 
-	return result, nil
+	seen := make(map[string]bool, len(values))
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" {
+			return ColumnDefinition{}, fmt.Errorf("%s column %s has an empty value", kind, name)
+		}
+		if seen[value] {
+			return ColumnDefinition{}, fmt.Errorf("%s column %s has duplicate value %q", kind, name, value)
+		}
+		seen[value] = true
+		quoted = append(quoted, quoteSQLString(value))
+	}
+
+	return ColumnDefinition{
+		Name: name,
+		Type: kind + "(" + strings.Join(quoted, ", ") + ")",
+	}, nil
+}
+
+// quoteSQLString returns value as a single-quoted SQL string literal.
+func quoteSQLString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "'", "''")
+	return "'" + value + "'"
+}
+
+// BoolPtr returns a pointer to v, for optional fields such as
+// ColumnDefinition.Nullable.
+func BoolPtr(v bool) *bool {
+	return &v
+}
+
+// StringPtr returns a pointer to v, for optional fields such as
+// ColumnDefinition.Default.
+func StringPtr(v string) *string {
+	return &v
 }
@@ -0,0 +1,92 @@
+package WOWSQL
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteQueryKeepsColumnOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"rows":[{"name":"Ada","id":1,"active":true},{"id":2,"name":"Grace","active":false}]}`))
+	}))
+	defer server.Close()
+
+	result, err := NewSchemaClient(server.URL, "wowsql_service_test").ExecuteQuery("SELECT name, id, active FROM users")
+	if err != nil {
+		t.Fatalf("ExecuteQuery: %v", err)
+	}
+	if want := []string{"name", "id", "active"}; !reflect.DeepEqual(result.Columns, want) {
+		t.Errorf("Columns = %v, want %v", result.Columns, want)
+	}
+	want := [][]interface{}{{"Ada", float64(1), true}, {"Grace", float64(2), false}}
+	if !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("Rows = %v, want %v", result.Rows, want)
+	}
+}
+
+func TestSchemaClientEscapesPathSegments(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	client := NewSchemaClient(server.URL, "wowsql_service_test")
+	client.DropIndex("my table", "idx/name")
+	client.DropForeignKey("orders", "fk?1")
+	client.DescribeTable("a#b")
+	client.RenameTable("old name", "new name")
+	client.TruncateTable("logs/2024", false)
+
+	want := []string{
+		"/api/v2/schema/tables/my%20table/indexes/idx%2Fname",
+		"/api/v2/schema/tables/orders/foreign-keys/fk%3F1",
+		"/api/v2/schema/tables/a%23b",
+		"/api/v2/schema/tables/old%20name/rename",
+		"/api/v2/schema/tables/logs%2F2024/truncate",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("requested %v, want %v", paths, want)
+	}
+}
+
+func TestDestructiveDryRunUsesQueryParameter(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("dry_run"))
+		w.Write([]byte(`{"success":true,"dry_run":true}`))
+	}))
+	defer server.Close()
+
+	client := NewSchemaClient(server.URL, "wowsql_service_test")
+	client.DropTable("logs", true, WithDryRun())
+	client.TruncateTable("logs", true, WithDryRun())
+	client.TruncateTable("logs", true)
+
+	if want := []string{"true", "true", ""}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("dry_run parameters %q, want %q", queries, want)
+	}
+}
+
+func TestNewSchemaClientWithConfig(t *testing.T) {
+	tests := []struct {
+		config SchemaConfig
+		want   string
+	}{
+		{SchemaConfig{ProjectURL: "myproject", Secure: true}, "https://myproject.wowsql.com"},
+		{SchemaConfig{ProjectURL: "myproject", BaseDomain: "example.dev"}, "http://myproject.example.dev"},
+		{SchemaConfig{ProjectURL: "https://db.example.dev/api", BaseDomain: "example.dev"}, "https://db.example.dev"},
+	}
+	for _, tt := range tests {
+		client := NewSchemaClientWithConfig(tt.config)
+		if client.baseURL != tt.want {
+			t.Errorf("NewSchemaClientWithConfig(%+v): base URL %q, want %q", tt.config, client.baseURL, tt.want)
+		}
+		if client.httpClient.Timeout != DefaultSchemaTimeout {
+			t.Errorf("timeout %v, want %v", client.httpClient.Timeout, DefaultSchemaTimeout)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package WOWSQL
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SessionStore persists auth sessions so they survive process restarts.
+// Implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Save stores the session. An empty session means the user signed out.
+	Save(session AuthSession) error
+	// Load returns the stored session, or an empty session if there is none.
+	Load() (AuthSession, error)
+}
+
+// FileSessionStore stores the session as JSON in a file readable only by the
+// current user.
+type FileSessionStore struct {
+	Path string
+}
+
+// NewFileSessionStore creates a session store backed by the file at path.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{Path: path}
+}
+
+// Save writes the session to the file with 0600 permissions.
+func (s *FileSessionStore) Save(session AuthSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	if dir := filepath.Dir(s.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+
+	if err := writeFileAtomic(s.Path, data); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file first so a crash never leaves a
+// torn file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Load reads the session from the file. A missing file yields an empty session.
+func (s *FileSessionStore) Load() (AuthSession, error) {
+	var session AuthSession
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return session, nil
+		}
+		return session, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &session); err != nil {
+		return session, fmt.Errorf("failed to parse session: %w", err)
+	}
+	return session, nil
+}
+
+// oauthStateDir is where pending OAuth flows are kept, next to the session.
+func (s *FileSessionStore) oauthStateDir() string {
+	return s.Path + ".oauth"
+}
+
+// oauthStatePath returns the file for state. The state comes from the
+// callback URL, so only the characters generateOAuthState produces are
+// accepted.
+func (s *FileSessionStore) oauthStatePath(state string) (string, error) {
+	if state == "" || strings.Trim(state, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+		return "", fmt.Errorf("malformed OAuth state: %w", ErrInvalidOAuthState)
+	}
+	return filepath.Join(s.oauthStateDir(), state+".json"), nil
+}
+
+// SaveOAuthState writes the flow to its own file with 0600 permissions and
+// removes files of expired flows.
+func (s *FileSessionStore) SaveOAuthState(state string, flow OAuthFlowState) error {
+	path, err := s.oauthStatePath(state)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(flow)
+	if err != nil {
+		return fmt.Errorf("failed to encode OAuth state: %w", err)
+	}
+
+	dir := s.oauthStateDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create OAuth state directory: %w", err)
+	}
+	s.pruneOAuthStates(dir)
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write OAuth state: %w", err)
+	}
+	return nil
+}
+
+// TakeOAuthState reads the flow stored under state and removes its file.
+// Only the caller whose remove succeeds gets the flow, so concurrent callbacks
+// with the same state cannot both use it.
+func (s *FileSessionStore) TakeOAuthState(state string) (OAuthFlowState, bool, error) {
+	var flow OAuthFlowState
+	path, err := s.oauthStatePath(state)
+	if err != nil {
+		return flow, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return flow, false, nil
+		}
+		return flow, false, fmt.Errorf("failed to read OAuth state: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return flow, false, nil
+		}
+		return flow, false, fmt.Errorf("failed to remove OAuth state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &flow); err != nil {
+		return flow, false, fmt.Errorf("failed to parse OAuth state: %w", err)
+	}
+	return flow, true, nil
+}
+
+// pruneOAuthStates removes files of flows older than OAuthStateTTL. Errors
+// are ignored; a leftover file is harmless.
+func (s *FileSessionStore) pruneOAuthStates(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= OAuthStateTTL {
+			continue
+		}
+		os.Remove(filepath.Join(dir, entry.Name()))
+	}
+}
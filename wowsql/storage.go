@@ -2,52 +2,230 @@ package WOWSQL
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultQuotaCacheTTL is how long a quota read is reused by upload quota checks.
+const DefaultQuotaCacheTTL = 30 * time.Second
+
 // StorageClient represents the S3 storage client
 type StorageClient struct {
 	projectURL     string
 	apiKey         string
 	httpClient     *http.Client
-	autoCheckQuota bool
+	autoCheckQuota atomic.Bool
+	bucket         string // empty for the project's default bucket
+	quota          *quotaCache
+	retry          *RetryPolicy
+	basePath       string // empty for DefaultStorageBasePath
+	publicBaseURL  string // empty for the storage API's public route
+	interceptors   []RequestInterceptor
+
+	tokenMu    sync.RWMutex // guards apiKey, serviceKey and userToken
+	userToken  string
+	serviceKey string // used for admin operations, see SetServiceKey
+}
+
+// quotaCache holds the last quota read. It is shared by a client and the
+// bucket clients derived from it, since the quota is per project.
+type quotaCache struct {
+	mu        sync.Mutex
+	quota     *StorageQuota
+	fetchedAt time.Time
+	ttl       time.Duration
+
+	warnThreshold float64
+	onWarning     func(QuotaWarning)
+}
+
+// QuotaWarning describes an upload that pushed storage usage past the
+// threshold set with SetQuotaWarning.
+type QuotaWarning struct {
+	// Key is the uploaded file that crossed the threshold.
+	Key            string
+	UploadedBytes  int64
+	UsedBytes      int64
+	RemainingBytes int64
+	QuotaBytes     int64
+	UsedPercent    float64
+	Threshold      float64
 }
 
-// NewStorageClient creates a new storage client
+// StorageConfig configures a storage client created with
+// NewStorageClientWithConfig. ProjectURL, BaseDomain and Secure are resolved
+// like the fields of AuthConfig.
+type StorageConfig struct {
+	ProjectURL string
+	BaseDomain string
+	Secure     bool
+	APIKey     string
+	// Timeout is the request timeout. Defaults to 60 seconds.
+	Timeout time.Duration
+	// DisableQuotaCheck turns off the quota check before uploads, see
+	// SetAutoCheckQuota.
+	DisableQuotaCheck bool
+}
+
+// NewStorageClient creates a new storage client.
+// projectURL may be a full URL or just the project slug, which resolves to
+// https://<slug>.wowsql.com; use NewStorageClientWithConfig for other domains.
 func NewStorageClient(projectURL, apiKey string) *StorageClient {
-	return &StorageClient{
-		projectURL:     projectURL,
-		apiKey:         apiKey,
-		autoCheckQuota: true,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}
+	return NewStorageClientWithOptions(projectURL, apiKey, 60*time.Second, true)
 }
 
 // NewStorageClientWithOptions creates a new storage client with options
 func NewStorageClientWithOptions(projectURL, apiKey string, timeout time.Duration, autoCheckQuota bool) *StorageClient {
-	return &StorageClient{
-		projectURL:     projectURL,
-		apiKey:         apiKey,
-		autoCheckQuota: autoCheckQuota,
+	return NewStorageClientWithConfig(StorageConfig{
+		ProjectURL:        projectURL,
+		Secure:            true,
+		APIKey:            apiKey,
+		Timeout:           timeout,
+		DisableQuotaCheck: !autoCheckQuota,
+	})
+}
+
+// NewStorageClientWithConfig creates a new storage client from config.
+func NewStorageClientWithConfig(config StorageConfig) *StorageClient {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	s := &StorageClient{
+		projectURL: ResolveProjectURL(config.ProjectURL, config.BaseDomain, config.Secure),
+		apiKey:     config.APIKey,
+		quota:      &quotaCache{ttl: DefaultQuotaCacheTTL},
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
 	}
+	s.autoCheckQuota.Store(!config.DisableQuotaCheck)
+	return s
+}
+
+// Bucket returns a client whose operations target the named bucket, which is
+// sent as the bucket query parameter of every request. It shares the
+// connection pool, settings and quota cache of s. An empty name selects the
+// project's default bucket.
+func (s *StorageClient) Bucket(name string) *StorageClient {
+	bucket := &StorageClient{
+		projectURL:   s.projectURL,
+		apiKey:       s.getAPIKey(),
+		httpClient:   s.httpClient,
+		bucket:       name,
+		quota:        s.quota,
+		retry:        s.retry,
+		basePath:     s.basePath,
+		interceptors: s.interceptors,
+		userToken:    s.UserToken(),
+		serviceKey:   s.getServiceKey(),
+	}
+	bucket.autoCheckQuota.Store(s.AutoCheckQuota())
+	return bucket
+}
+
+// SetUserToken makes requests carry the signed-in user's access token as the
+// bearer token, so per-user storage policies based on the JWT apply. The API
+// key is still sent in the X-API-Key header to identify the project. Pass ""
+// to go back to authenticating with the API key alone.
+func (s *StorageClient) SetUserToken(token string) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	s.userToken = token
+}
+
+// SetAPIKey replaces the API key sent with every request, e.g. when keys are
+// rotated. It is safe to call while other goroutines use the client. Bucket
+// clients created earlier keep the key they were created with.
+func (s *StorageClient) SetAPIKey(key string) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	s.apiKey = key
+}
+
+// SetServiceKey sets a SERVICE ROLE key used only for admin operations
+// (ProvisionStorage and region listing), so the client can otherwise keep
+// using an anonymous key. Without it, admin operations use the API key if it
+// is a service key and fail with ErrServiceKeyRequired otherwise.
+//
+// ⚠️ IMPORTANT: Never ship a service key to browsers or mobile apps!
+func (s *StorageClient) SetServiceKey(key string) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	s.serviceKey = key
+}
+
+func (s *StorageClient) getServiceKey() string {
+	s.tokenMu.RLock()
+	defer s.tokenMu.RUnlock()
+	return s.serviceKey
+}
+
+// admin returns a client that authenticates with the service key alone, for
+// operations that need elevated privileges.
+func (s *StorageClient) admin(operation string) (*StorageClient, error) {
+	key := s.getServiceKey()
+	if key == "" {
+		if apiKey := s.getAPIKey(); isServiceKey(apiKey) {
+			key = apiKey
+		}
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%s requires a service role key, set one with SetServiceKey: %w", operation, ErrServiceKeyRequired)
+	}
+	admin := s.Bucket(s.bucket)
+	admin.apiKey = key
+	admin.userToken = ""
+	return admin, nil
+}
+
+// isServiceKey reports whether key has a service role key prefix.
+func isServiceKey(key string) bool {
+	return strings.HasPrefix(key, "wowsql_service_") || strings.HasPrefix(key, "service_")
+}
+
+func (s *StorageClient) getAPIKey() string {
+	s.tokenMu.RLock()
+	defer s.tokenMu.RUnlock()
+	return s.apiKey
+}
+
+// UserToken returns the user access token set with SetUserToken.
+func (s *StorageClient) UserToken() string {
+	s.tokenMu.RLock()
+	defer s.tokenMu.RUnlock()
+	return s.userToken
+}
+
+// BucketName returns the bucket targeted by s, or "" for the default bucket.
+func (s *StorageClient) BucketName() string {
+	return s.bucket
 }
 
 // GetQuota retrieves storage quota information
 func (s *StorageClient) GetQuota() (*StorageQuota, error) {
-	resp, err := s.doRequest("GET", "/api/v1/storage/quota", nil)
+	return s.GetQuotaContext(context.Background())
+}
+
+// GetQuotaContext is like GetQuota but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetQuotaContext(ctx context.Context) (*StorageQuota, error) {
+	resp, err := s.doRequest(ctx, "GET", "/api/v1/storage/quota", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,194 +235,1037 @@ func (s *StorageClient) GetQuota() (*StorageQuota, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	cached := quota
+	s.quota.mu.Lock()
+	s.quota.quota = &cached
+	s.quota.fetchedAt = time.Now()
+	s.quota.mu.Unlock()
+
 	return &quota, nil
 }
 
-// Upload uploads a file to storage
-func (s *StorageClient) Upload(fileData []byte, key string, contentType string, checkQuota *bool) (*FileUploadResult, error) {
-	shouldCheck := s.autoCheckQuota
+// Ping checks that the project is reachable and the API key is accepted by
+// reading the storage quota. The error says whether the key was rejected or
+// the host is unreachable.
+func (s *StorageClient) Ping() error {
+	return s.PingContext(context.Background())
+}
+
+// PingContext is like Ping but uses ctx for cancellation and deadlines.
+func (s *StorageClient) PingContext(ctx context.Context) error {
+	_, err := s.GetQuotaContext(ctx)
+	return pingError(err)
+}
+
+// SetTimeout sets the timeout for each request, including the transfer of
+// uploaded and downloaded data. Calls made with a context that has a deadline
+// use that deadline instead, e.g. to give one large upload more time. Bucket
+// clients derived from s share the setting. Call it before the client is
+// shared between goroutines.
+func (s *StorageClient) SetTimeout(timeout time.Duration) {
+	s.httpClient.Timeout = timeout
+}
+
+// SetBasePath sets the path the storage API is mounted under, replacing
+// DefaultStorageBasePath, e.g. for a self-hosted backend. An empty basePath
+// restores the default. Bucket clients created afterwards inherit it. Call it
+// before the client is shared between goroutines.
+func (s *StorageClient) SetBasePath(basePath string) {
+	s.basePath = normalizeBasePath(basePath)
+}
+
+// SetPublicBaseURL sets the URL that public objects are served under, e.g. a
+// CDN in front of the bucket, for GetPublicURL. The object key is appended to
+// it; the bucket is not. Pass "" to go back to the storage API's public
+// route. Bucket clients do not inherit it, since it belongs to one bucket.
+// Call it before the client is shared between goroutines.
+func (s *StorageClient) SetPublicBaseURL(baseURL string) {
+	s.publicBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// WithRequestInterceptor adds fn to the interceptors run on every API
+// request before it is sent, in the order they were added. Downloads from
+// presigned URLs are not intercepted, since extra headers can break their
+// signature. Bucket clients created afterwards inherit the interceptors.
+// Call it before the client is shared between goroutines.
+func (s *StorageClient) WithRequestInterceptor(fn RequestInterceptor) *StorageClient {
+	// Copy so bucket clients sharing the old slice are not affected.
+	s.interceptors = append(s.interceptors[:len(s.interceptors):len(s.interceptors)], fn)
+	return s
+}
+
+// SetAutoCheckQuota turns the quota check that uploads make by default on or
+// off, e.g. to skip the GetQuota round-trip during a bulk migration and turn
+// it back on afterwards. A checkQuota argument passed to an upload still takes
+// precedence. It is safe to call while other goroutines use the client; bucket
+// clients created earlier keep their own setting.
+//
+// ⚠️ With the check off, uploads over quota are only rejected by the server,
+// after the data has been sent, and SetQuotaWarning is not triggered.
+func (s *StorageClient) SetAutoCheckQuota(enabled bool) {
+	s.autoCheckQuota.Store(enabled)
+}
+
+// AutoCheckQuota reports whether uploads check the quota by default.
+func (s *StorageClient) AutoCheckQuota() bool {
+	return s.autoCheckQuota.Load()
+}
+
+// SetQuotaCacheTTL sets how long upload quota checks reuse a previous quota
+// read (DefaultQuotaCacheTTL by default). A TTL of zero disables the cache.
+func (s *StorageClient) SetQuotaCacheTTL(ttl time.Duration) {
+	s.quota.mu.Lock()
+	defer s.quota.mu.Unlock()
+	s.quota.ttl = ttl
+}
+
+// InvalidateQuotaCache discards the cached quota so the next upload check
+// reads it from the server, e.g. after provisioning more storage.
+func (s *StorageClient) InvalidateQuotaCache() {
+	s.quota.mu.Lock()
+	defer s.quota.mu.Unlock()
+	s.quota.quota = nil
+}
+
+// availableBytes returns the storage still available, using the quota cache
+// when it is fresh enough.
+func (s *StorageClient) availableBytes(ctx context.Context) (int64, error) {
+	c := s.quota
+	c.mu.Lock()
+	if c.quota != nil && c.ttl > 0 && time.Since(c.fetchedAt) < c.ttl {
+		available := c.quota.StorageAvailableBytes
+		c.mu.Unlock()
+		return available, nil
+	}
+	c.mu.Unlock()
+
+	quota, err := s.GetQuotaContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return quota.StorageAvailableBytes, nil
+}
+
+// SetQuotaWarning registers fn to be called when a successful upload takes
+// storage usage from below threshold percent to at or above it, e.g. 90, so
+// apps can alert admins before uploads start failing with
+// StorageLimitExceededError. Usage is tracked through the quota the upload
+// check reads, so no warning fires for uploads made with the check disabled.
+// fn runs on the uploading goroutine. The setting is shared with Bucket
+// clients. Pass a nil fn to remove it.
+func (s *StorageClient) SetQuotaWarning(threshold float64, fn func(QuotaWarning)) {
+	s.quota.mu.Lock()
+	defer s.quota.mu.Unlock()
+	s.quota.warnThreshold = threshold
+	s.quota.onWarning = fn
+}
+
+// consumeQuota updates the cached quota after a successful upload of key,
+// fires the quota warning if the upload crossed its threshold, and returns
+// the remaining bytes, or -1 if no quota is cached.
+func (s *StorageClient) consumeQuota(key string, size int64) int64 {
+	c := s.quota
+	c.mu.Lock()
+	if c.quota == nil {
+		c.mu.Unlock()
+		return -1
+	}
+	before := c.quota.UsedPercent()
+	c.quota.StorageAvailableBytes -= size
+	c.quota.StorageUsedBytes += size
+	after := *c.quota
+	onWarning, threshold := c.onWarning, c.warnThreshold
+	c.mu.Unlock()
+
+	if onWarning != nil && before < threshold && after.UsedPercent() >= threshold {
+		onWarning(QuotaWarning{
+			Key:            key,
+			UploadedBytes:  size,
+			UsedBytes:      after.StorageUsedBytes,
+			RemainingBytes: after.StorageAvailableBytes,
+			QuotaBytes:     after.StorageQuotaBytes,
+			UsedPercent:    after.UsedPercent(),
+			Threshold:      threshold,
+		})
+	}
+	return after.StorageAvailableBytes
+}
+
+// UploadOption configures a single upload.
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	progress func(bytesSent, totalBytes int64)
+	checksum ChecksumAlgorithm
+	metadata map[string]string
+	tags     map[string]string
+
+	idempotencyKey string
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header of the upload, so the
+// server can recognize a repeated attempt and store the file only once. When
+// not set, a key is generated for each call and reused by its retries; either
+// way it is returned in FileUploadResult.IdempotencyKey. The backend must
+// honor the header for it to have any effect.
+func WithIdempotencyKey(key string) UploadOption {
+	return func(o *uploadOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithProgress reports upload progress. The callback is invoked from the
+// uploading goroutine each time a chunk of file data is sent, and once more
+// with bytesSent == totalBytes when the file has been fully sent.
+func WithProgress(fn func(bytesSent, totalBytes int64)) UploadOption {
+	return func(o *uploadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithMetadata attaches custom metadata (e.g. owner IDs, cache-control or
+// content-disposition) to the uploaded object.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithTags attaches tags to the uploaded object.
+func WithTags(tags map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.tags = tags
+	}
+}
+
+// DownloadOption configures a single download.
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	checksum            ChecksumAlgorithm
+	expectedChecksum    string
+	contentDisposition  string
+	responseContentType string
+	transform           *TransformOptions
+}
+
+// WithContentDisposition sets the Content-Disposition header served with a
+// presigned download URL, e.g. `attachment; filename="report.pdf"`.
+func WithContentDisposition(disposition string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.contentDisposition = disposition
+	}
+}
+
+// WithAttachmentFilename makes browsers save a presigned download as filename
+// instead of using the object key.
+func WithAttachmentFilename(filename string) DownloadOption {
+	return WithContentDisposition(mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
+
+// WithResponseContentType overrides the Content-Type served with a presigned
+// download URL.
+func WithResponseContentType(contentType string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.responseContentType = contentType
+	}
+}
+
+// responseParams returns the response overrides and image transform to sign
+// into a presigned URL.
+func (o *downloadOptions) responseParams() (url.Values, error) {
+	params := url.Values{}
+	if o.contentDisposition != "" {
+		params.Set("response_content_disposition", o.contentDisposition)
+	}
+	if o.responseContentType != "" {
+		params.Set("response_content_type", o.responseContentType)
+	}
+	if o.transform != nil {
+		if err := o.transform.validate(); err != nil {
+			return nil, err
+		}
+		o.transform.params(params)
+	}
+	return params, nil
+}
+
+// Upload uploads a file to storage.
+// If contentType is empty it is detected, in order of precedence, from the
+// key's file extension and then by sniffing the first 512 bytes of fileData.
+func (s *StorageClient) Upload(fileData []byte, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	return s.UploadContext(context.Background(), fileData, key, contentType, checkQuota, opts...)
+}
+
+// UploadContext is like Upload but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadContext(ctx context.Context, fileData []byte, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	if contentType == "" {
+		contentType = detectContentType(key, fileData)
+	}
+	return s.UploadStreamContext(ctx, bytes.NewReader(fileData), int64(len(fileData)), key, contentType, checkQuota, opts...)
+}
+
+// UploadAndSign uploads a file like Upload and then presigns a download URL
+// for it, valid for expiresIn seconds, in FileUploadResult.SignedURL. If the
+// upload succeeds but signing fails, the result is returned with the error.
+// For objects in a public bucket, GetPublicURL gives a URL without the extra
+// request.
+func (s *StorageClient) UploadAndSign(fileData []byte, key string, contentType string, expiresIn int, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	return s.UploadAndSignContext(context.Background(), fileData, key, contentType, expiresIn, checkQuota, opts...)
+}
+
+// UploadAndSignContext is like UploadAndSign but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadAndSignContext(ctx context.Context, fileData []byte, key string, contentType string, expiresIn int, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	result, err := s.UploadContext(ctx, fileData, key, contentType, checkQuota, opts...)
+	if err != nil {
+		return result, err
+	}
+
+	signedURL, err := s.GetPresignedUrlContext(ctx, result.Key, expiresIn, "get_object")
+	if err != nil {
+		return result, fmt.Errorf("file uploaded but failed to presign URL: %w", err)
+	}
+	result.SignedURL = signedURL
+	return result, nil
+}
+
+// UploadStream uploads a file read from reader without buffering it in memory.
+// size must be the exact number of bytes reader will produce; it is used for
+// the quota check and the request Content-Length. If contentType is empty it
+// is detected from the key's file extension; the content is not sniffed.
+// With WithRetry, the upload is retried only if reader implements io.Seeker.
+func (s *StorageClient) UploadStream(reader io.Reader, size int64, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	return s.UploadStreamContext(context.Background(), reader, size, key, contentType, checkQuota, opts...)
+}
+
+// UploadStreamContext is like UploadStream but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadStreamContext(ctx context.Context, reader io.Reader, size int64, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	var options uploadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	shouldCheck := s.AutoCheckQuota()
 	if checkQuota != nil {
 		shouldCheck = *checkQuota
 	}
 
 	// Check quota if enabled
 	if shouldCheck {
-		quota, err := s.GetQuota()
+		available, err := s.availableBytes(ctx)
 		if err != nil {
 			return nil, err
 		}
 
-		if quota.StorageAvailableBytes < int64(len(fileData)) {
+		if available < size {
 			return nil, &StorageLimitExceededError{
-				Message:        fmt.Sprintf("Storage limit exceeded. Need %s, but only %s available.", formatBytes(int64(len(fileData))), formatBytes(quota.StorageAvailableBytes)),
-				RequiredBytes:  int64(len(fileData)),
-				AvailableBytes: quota.StorageAvailableBytes,
+				Message:        fmt.Sprintf("Storage limit exceeded. Need %s, but only %s available.", FormatBytes(size), FormatBytes(available)),
+				RequiredBytes:  size,
+				AvailableBytes: available,
 			}
 		}
 	}
 
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Add key field
-	if err := writer.WriteField("key", key); err != nil {
-		return nil, fmt.Errorf("failed to write key field: %w", err)
+	if contentType == "" {
+		contentType = detectContentType(key, nil)
 	}
 
+	fields := [][2]string{{"key", key}}
 	// Add content type if provided
 	if contentType != "" {
-		if err := writer.WriteField("content_type", contentType); err != nil {
-			return nil, fmt.Errorf("failed to write content_type field: %w", err)
+		fields = append(fields, [2]string{"content_type", contentType})
+	}
+	for _, field := range []struct {
+		name   string
+		values map[string]string
+	}{{"metadata", options.metadata}, {"tags", options.tags}} {
+		if len(field.values) == 0 {
+			continue
 		}
+		encoded, err := json.Marshal(field.values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", field.name, err)
+		}
+		fields = append(fields, [2]string{field.name, string(encoded)})
+	}
+
+	if options.idempotencyKey == "" {
+		options.idempotencyKey = NewIdempotencyKey()
 	}
+	headers := map[string]string{IdempotencyKeyHeader: options.idempotencyKey}
 
-	// Add file
-	part, err := writer.CreateFormFile("file", key)
+	// Uploads are retried only when the data can be read again from the start.
+	attempts := 1
+	rewind := rewinder(reader)
+	if s.retry.enabled() && rewind != nil {
+		attempts = s.retry.MaxAttempts
+	}
+
+	var (
+		respBody []byte
+		h        hash.Hash
+	)
+	err := s.retryTransient(ctx, attempts, rewind, func() error {
+		file := reader
+		if options.progress != nil {
+			file = &progressReader{reader: file, total: size, callback: options.progress}
+		}
+
+		h = nil
+		if options.checksum != "" {
+			var err error
+			if h, err = options.checksum.newHash(); err != nil {
+				return err
+			}
+			file = io.TeeReader(file, h)
+		}
+
+		body, formContentType, length, err := newMultipartBody(fields, key, file, size)
+		if err != nil {
+			return err
+		}
+
+		respBody, _, err = s.send(ctx, "POST", "/api/v1/storage/upload", body, formContentType, length, headers)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
+		return nil, err
+	}
+
+	var result FileUploadResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	result.IdempotencyKey = options.idempotencyKey
+	if result.Key == "" {
+		result.Key = key
 	}
+	if result.ContentType == "" {
+		result.ContentType = contentType
+	}
+
+	uploaded := result.Size
+	if uploaded <= 0 {
+		uploaded = size
+	}
+	result.RemainingBytes = s.consumeQuota(key, uploaded)
 
-	if _, err := part.Write(fileData); err != nil {
-		return nil, fmt.Errorf("failed to write file data: %w", err)
+	if h != nil {
+		if err := verifyUploadChecksum(options.checksum, h, &result); err != nil {
+			return &result, err
+		}
 	}
 
+	return &result, nil
+}
+
+// detectContentType guesses a MIME type from the key's extension, falling back
+// to sniffing data. It returns "" when neither gives an answer.
+func detectContentType(key string, data []byte) string {
+	if ext := path.Ext(key); ext != "" {
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+	if len(data) > 0 {
+		if len(data) > 512 {
+			data = data[:512]
+		}
+		return http.DetectContentType(data)
+	}
+	return ""
+}
+
+// progressReader reports how many bytes have been read from the wrapped reader.
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	sent     int64
+	reported bool
+	callback func(bytesSent, totalBytes int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.reported = r.sent == r.total
+		r.callback(r.sent, r.total)
+	}
+	if err == io.EOF && !r.reported {
+		// Report the final total once it is known.
+		r.reported = true
+		r.total = r.sent
+		r.callback(r.sent, r.total)
+	}
+	return n, err
+}
+
+// newMultipartBody builds a multipart/form-data body that streams file between
+// the pre-rendered form fields and closing boundary. The returned length is -1
+// when size is unknown (negative).
+func newMultipartBody(fields [][2]string, filename string, file io.Reader, size int64) (io.Reader, string, int64, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return nil, "", 0, fmt.Errorf("failed to write %s field: %w", field[0], err)
+		}
+	}
+
+	if _, err := writer.CreateFormFile("file", filename); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create form file: %w", err)
+	}
+	head := append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+
 	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	tail := buf.Bytes()
+
+	length := int64(-1)
+	if size >= 0 {
+		length = int64(len(head)) + size + int64(len(tail))
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), file, bytes.NewReader(tail))
+	return body, writer.FormDataContentType(), length, nil
+}
+
+// Download gets a presigned URL for downloading a file.
+// Use WithAttachmentFilename, WithContentDisposition or WithResponseContentType
+// to control how browsers handle the URL, and WithTransform to serve a
+// resized image.
+func (s *StorageClient) Download(key string, expiresIn int, opts ...DownloadOption) (string, error) {
+	return s.DownloadContext(context.Background(), key, expiresIn, opts...)
+}
+
+// DownloadContext is like Download but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DownloadContext(ctx context.Context, key string, expiresIn int, opts ...DownloadOption) (string, error) {
+	var options downloadOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
 
-	// Make request
-	url := s.projectURL + "/api/v1/storage/upload"
-	req, err := http.NewRequest("POST", url, body)
+	reqPath := fmt.Sprintf("/api/v1/storage/download?key=%s&expires_in=%d", key, expiresIn)
+	params, err := options.responseParams()
+	if err != nil {
+		return "", err
+	}
+	if len(params) > 0 {
+		reqPath += "&" + params.Encode()
+	}
+	resp, err := s.doRequest(ctx, "GET", reqPath, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	var result struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.URL, nil
+}
+
+// DownloadToWriter streams the content of a file into w without buffering it
+// in memory. When a checksum is verified, the data has already been written to
+// w by the time a mismatch is reported.
+func (s *StorageClient) DownloadToWriter(key string, w io.Writer, opts ...DownloadOption) error {
+	return s.DownloadToWriterContext(context.Background(), key, w, opts...)
+}
+
+// DownloadToWriterContext is like DownloadToWriter but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DownloadToWriterContext(ctx context.Context, key string, w io.Writer, opts ...DownloadOption) error {
+	var options downloadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var h hash.Hash
+	if options.checksum != "" {
+		var err error
+		if h, err = options.checksum.newHash(); err != nil {
+			return err
+		}
+		w = io.MultiWriter(w, h)
+	}
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.openDownload(ctx, key, "")
 	if err != nil {
-		return nil, &StorageError{Err: err}
+		return err
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return &StorageError{Message: "failed to read file content", Err: err}
+	}
+
+	if h != nil {
+		return compareChecksum(options.checksum, key, options.expectedChecksum, h)
+	}
+	return nil
+}
+
+// DownloadBytes downloads the full content of a file into memory.
+func (s *StorageClient) DownloadBytes(key string, opts ...DownloadOption) ([]byte, error) {
+	return s.DownloadBytesContext(context.Background(), key, opts...)
+}
+
+// DownloadBytesContext is like DownloadBytes but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DownloadBytesContext(ctx context.Context, key string, opts ...DownloadOption) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.DownloadToWriterContext(ctx, key, &buf, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadRange streams bytes start through end (inclusive) of a file into w.
+// Pass a negative end to read from start to the end of the file. It fails if
+// the server ignores the range and sends the whole file.
+func (s *StorageClient) DownloadRange(key string, start, end int64, w io.Writer) error {
+	return s.DownloadRangeContext(context.Background(), key, start, end, w)
+}
+
+// DownloadRangeContext is like DownloadRange but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DownloadRangeContext(ctx context.Context, key string, start, end int64, w io.Writer) error {
+	if start < 0 {
+		return fmt.Errorf("start must not be negative")
+	}
+	rangeHeader := fmt.Sprintf("bytes=%d-", start)
+	if end >= 0 {
+		if end < start {
+			return fmt.Errorf("end must not be before start")
+		}
+		rangeHeader = fmt.Sprintf("bytes=%d-%d", start, end)
+	}
+
+	resp, err := s.openDownload(ctx, key, rangeHeader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, parseStorageError(resp.StatusCode, respBody)
+	if resp.StatusCode != http.StatusPartialContent {
+		return &StorageError{
+			Message:    "server ignored the requested range",
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return &StorageError{Message: "failed to read file content", Err: err}
+	}
+	return nil
+}
+
+// openDownload resolves a short-lived presigned URL for key and starts
+// fetching it, optionally limited to rangeHeader. The caller must close the
+// response body.
+func (s *StorageClient) openDownload(ctx context.Context, key, rangeHeader string) (*http.Response, error) {
+	downloadURL, err := s.DownloadContext(ctx, key, 300)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := 1
+	if s.retry.enabled() {
+		attempts = s.retry.MaxAttempts
+	}
+
+	// Only opening the download is retried; once the body is handed to the
+	// caller, a failure part-way through is returned as is.
+	var resp *http.Response
+	err = s.retryTransient(ctx, attempts, nil, func() error {
+		// The presigned URL carries its own signature, so no Authorization header.
+		req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+
+		resp, err = httpClientFor(ctx, s.httpClient).Do(req)
+		if err != nil {
+			return &StorageError{Err: &NetworkError{Err: err}}
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+			return withRetryAfter(parseStorageError(resp.StatusCode, respBody), resp.Header)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ListFiles lists files in storage
+func (s *StorageClient) ListFiles(prefix string, limit int) ([]StorageFile, error) {
+	return s.ListFilesContext(context.Background(), prefix, limit)
+}
+
+// ListFilesContext is like ListFiles but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListFilesContext(ctx context.Context, prefix string, limit int) ([]StorageFile, error) {
+	url := "/api/v1/storage/list"
+	if prefix != "" || limit > 0 {
+		url += "?"
+		if prefix != "" {
+			url += fmt.Sprintf("prefix=%s", prefix)
+		}
+		if limit > 0 {
+			if prefix != "" {
+				url += "&"
+			}
+			url += fmt.Sprintf("limit=%d", limit)
+		}
+	}
+
+	resp, err := s.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Files []StorageFile `json:"files"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Files, nil
+}
+
+// ListFilesPaged lists one page of files. Pass the returned nextCursor to
+// fetch the following page; it is empty when there are no more results.
+func (s *StorageClient) ListFilesPaged(prefix string, limit int, cursor string) (files []StorageFile, nextCursor string, err error) {
+	return s.ListFilesPagedContext(context.Background(), prefix, limit, cursor)
+}
+
+// ListFilesPagedContext is like ListFilesPaged but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListFilesPagedContext(ctx context.Context, prefix string, limit int, cursor string) (files []StorageFile, nextCursor string, err error) {
+	query := url.Values{}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if limit > 0 {
+		query.Set("limit", fmt.Sprint(limit))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	return s.listPage(ctx, query)
+}
+
+// listPage fetches one page of the file listing for the given query.
+func (s *StorageClient) listPage(ctx context.Context, query url.Values) ([]StorageFile, string, error) {
+	path := "/api/v1/storage/list"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := s.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result struct {
+		Files      []StorageFile `json:"files"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Files, result.NextCursor, nil
+}
+
+// ListAllFiles lists every file under prefix, following pagination cursors
+// until the last page.
+func (s *StorageClient) ListAllFiles(prefix string) ([]StorageFile, error) {
+	return s.ListAllFilesContext(context.Background(), prefix)
+}
+
+// ListAllFilesContext is like ListAllFiles but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListAllFilesContext(ctx context.Context, prefix string) ([]StorageFile, error) {
+	var all []StorageFile
+	cursor := ""
+	for {
+		files, next, err := s.ListFilesPagedContext(ctx, prefix, 0, cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, files...)
+		if next == "" || next == cursor {
+			return all, nil
+		}
+		cursor = next
+	}
+}
+
+// DeleteFile deletes a single file
+func (s *StorageClient) DeleteFile(key string) error {
+	return s.DeleteFileContext(context.Background(), key)
+}
+
+// DeleteFileContext is like DeleteFile but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DeleteFileContext(ctx context.Context, key string) error {
+	body := map[string]interface{}{
+		"key": key,
+	}
+
+	_, err := s.doRequest(ctx, "DELETE", "/api/v1/storage/delete", body)
+	return err
+}
+
+// DeleteFiles deletes multiple files. Keys that fail individually (for
+// example because they do not exist) are reported in the result's Errors
+// rather than as an error; the error is only set when the request itself
+// fails.
+func (s *StorageClient) DeleteFiles(keys []string) (*DeleteResult, error) {
+	return s.DeleteFilesContext(context.Background(), keys)
+}
+
+// DeleteFilesContext is like DeleteFiles but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DeleteFilesContext(ctx context.Context, keys []string) (*DeleteResult, error) {
+	body := map[string]interface{}{
+		"keys": keys,
+	}
+
+	resp, err := s.doRequest(ctx, "DELETE", "/api/v1/storage/delete-batch", body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeleteResult(resp, keys)
+}
+
+// parseDeleteResult reads a batch delete response. Errors may be sent as a
+// key->reason object or as a list of {key, error} entries. When the server
+// does not list the deleted keys, every requested key without an error is
+// assumed deleted.
+func parseDeleteResult(resp []byte, keys []string) (*DeleteResult, error) {
+	var raw struct {
+		Deleted []string        `json:"deleted"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+	if len(bytes.TrimSpace(resp)) > 0 {
+		if err := json.Unmarshal(resp, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	result := &DeleteResult{Deleted: raw.Deleted, Errors: map[string]string{}}
+	if len(raw.Errors) > 0 && string(raw.Errors) != "null" {
+		if err := json.Unmarshal(raw.Errors, &result.Errors); err != nil {
+			var entries []struct {
+				Key     string `json:"key"`
+				Error   string `json:"error"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(raw.Errors, &entries); err != nil {
+				return nil, fmt.Errorf("failed to parse response: %w", err)
+			}
+			for _, entry := range entries {
+				reason := entry.Error
+				if reason == "" {
+					reason = entry.Message
+				}
+				result.Errors[entry.Key] = reason
+			}
+		}
 	}
 
-	var result FileUploadResult
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if raw.Deleted == nil {
+		result.Deleted = make([]string, 0, len(keys))
+		for _, key := range keys {
+			if _, failed := result.Errors[key]; !failed {
+				result.Deleted = append(result.Deleted, key)
+			}
+		}
 	}
 
-	return &result, nil
+	return result, nil
 }
 
-// Download gets a presigned URL for downloading a file
-func (s *StorageClient) Download(key string, expiresIn int) (string, error) {
-	url := fmt.Sprintf("/api/v1/storage/download?key=%s&expires_in=%d", key, expiresIn)
-	resp, err := s.doRequest("GET", url, nil)
-	if err != nil {
-		return "", err
-	}
+// DeleteByPrefix deletes every file under prefix. It walks the listing one
+// page at a time and deletes each page with a single DeleteFiles call, so
+// only one page is held in memory. It returns how many files were deleted,
+// including when it stops early because of an error. An empty prefix is
+// rejected so a bucket cannot be emptied by accident.
+//
+// ⚠️ WARNING: This operation cannot be undone!
+func (s *StorageClient) DeleteByPrefix(prefix string) (int, error) {
+	return s.DeleteByPrefixContext(context.Background(), prefix)
+}
 
-	var result struct {
-		URL string `json:"url"`
-	}
-	if err := json.Unmarshal(resp, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+// DeleteByPrefixContext is like DeleteByPrefix but uses ctx for cancellation and deadlines.
+func (s *StorageClient) DeleteByPrefixContext(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("prefix is required")
 	}
 
-	return result.URL, nil
-}
+	deleted := 0
+	it := s.ListFilesIterContext(ctx, prefix)
+	for {
+		files, ok := it.nextPage()
+		if !ok {
+			return deleted, it.Err()
+		}
+		if len(files) == 0 {
+			continue
+		}
 
-// ListFiles lists files in storage
-func (s *StorageClient) ListFiles(prefix string, limit int) ([]StorageFile, error) {
-	url := "/api/v1/storage/list"
-	if prefix != "" || limit > 0 {
-		url += "?"
-		if prefix != "" {
-			url += fmt.Sprintf("prefix=%s", prefix)
+		keys := make([]string, 0, len(files))
+		for _, file := range files {
+			keys = append(keys, file.Key)
 		}
-		if limit > 0 {
-			if prefix != "" {
-				url += "&"
-			}
-			url += fmt.Sprintf("limit=%d", limit)
+		result, err := s.DeleteFilesContext(ctx, keys)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += len(result.Deleted)
+		if !result.OK() {
+			return deleted, &StorageError{Message: fmt.Sprintf("failed to delete %d of %d files", len(result.Errors), len(keys))}
 		}
 	}
+}
+
+// GetFileInfo gets information about a file
+func (s *StorageClient) GetFileInfo(key string) (*StorageFile, error) {
+	return s.GetFileInfoContext(context.Background(), key)
+}
 
-	resp, err := s.doRequest("GET", url, nil)
+// GetFileInfoContext is like GetFileInfo but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetFileInfoContext(ctx context.Context, key string) (*StorageFile, error) {
+	path := "/api/v1/storage/info?key=" + url.QueryEscape(key)
+	resp, err := s.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var result struct {
-		Files []StorageFile `json:"files"`
-	}
-	if err := json.Unmarshal(resp, &result); err != nil {
+	var file StorageFile
+	if err := json.Unmarshal(resp, &file); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return result.Files, nil
+	return &file, nil
 }
 
-// DeleteFile deletes a single file
-func (s *StorageClient) DeleteFile(key string) error {
+// SetFileMetadata replaces the custom metadata of an existing object.
+func (s *StorageClient) SetFileMetadata(key string, metadata map[string]string) error {
+	return s.SetFileMetadataContext(context.Background(), key, metadata)
+}
+
+// SetFileMetadataContext is like SetFileMetadata but uses ctx for cancellation and deadlines.
+func (s *StorageClient) SetFileMetadataContext(ctx context.Context, key string, metadata map[string]string) error {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
 	body := map[string]interface{}{
-		"key": key,
+		"key":      key,
+		"metadata": metadata,
 	}
 
-	_, err := s.doRequest("DELETE", "/api/v1/storage/delete", body)
+	_, err := s.doRequest(ctx, "PATCH", "/api/v1/storage/metadata", body)
 	return err
 }
 
-// DeleteFiles deletes multiple files
-func (s *StorageClient) DeleteFiles(keys []string) error {
-	body := map[string]interface{}{
-		"keys": keys,
+// HeadFile checks whether a file exists and returns its size. The size comes
+// from the X-File-Size header of a HEAD request; if the server does not send
+// it or does not allow HEAD, it is read with GetFileInfo. A missing file
+// gives exists == false and a nil error. If the caller may not access the
+// file, the error is a *PermissionError.
+func (s *StorageClient) HeadFile(key string) (exists bool, size int64, err error) {
+	return s.HeadFileContext(context.Background(), key)
+}
+
+// HeadFileContext is like HeadFile but uses ctx for cancellation and deadlines.
+func (s *StorageClient) HeadFileContext(ctx context.Context, key string) (exists bool, size int64, err error) {
+	header, ok, err := s.headFile(ctx, key)
+	if err != nil {
+		return false, 0, headFileError(err, key)
+	}
+	if ok {
+		if size, err := strconv.ParseInt(header.Get("X-File-Size"), 10, 64); err == nil {
+			return true, size, nil
+		}
 	}
 
-	_, err := s.doRequest("DELETE", "/api/v1/storage/delete-batch", body)
-	return err
+	// The server does not support HEAD or does not report the size in a
+	// header, so read it from the file info.
+	info, err := s.GetFileInfoContext(ctx, key)
+	if err != nil {
+		return false, 0, headFileError(err, key)
+	}
+	return true, info.Size, nil
 }
 
-// GetFileInfo gets information about a file
-func (s *StorageClient) GetFileInfo(key string) (*StorageFile, error) {
-	url := fmt.Sprintf("/api/v1/storage/info?key=%s", key)
-	resp, err := s.doRequest("GET", url, nil)
+// headFile sends a HEAD request for key. ok is false if the server does not
+// support HEAD, in which case the caller falls back to GetFileInfo.
+func (s *StorageClient) headFile(ctx context.Context, key string) (header http.Header, ok bool, err error) {
+	path := "/api/v1/storage/info?key=" + url.QueryEscape(key)
+	_, header, err = s.send(ctx, "HEAD", path, nil, "application/json", -1, nil)
+	var storageErr *StorageError
+	if errors.As(err, &storageErr) && (storageErr.StatusCode == 405 || storageErr.StatusCode == 501) {
+		return nil, false, nil
+	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	return header, true, nil
+}
 
-	var file StorageFile
-	if err := json.Unmarshal(resp, &file); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// headFileError maps a failed lookup of key to HeadFile's results.
+func headFileError(err error, key string) error {
+	if errors.Is(err, ErrNotFound) {
+		return nil
 	}
-
-	return &file, nil
+	var storageErr *StorageError
+	if errors.As(err, &storageErr) && storageErr.StatusCode == 403 {
+		return &PermissionError{StorageError: *storageErr, Key: key}
+	}
+	return err
 }
 
-// FileExists checks if a file exists
+// FileExists checks if a file exists with a HEAD request, falling back to
+// GetFileInfo only if the server does not support HEAD. A missing file gives
+// (false, nil); a file the caller may not access gives
+// (false, *PermissionError), since it may well exist.
 func (s *StorageClient) FileExists(key string) (bool, error) {
-	_, err := s.GetFileInfo(key)
+	return s.FileExistsContext(context.Background(), key)
+}
+
+// FileExistsContext is like FileExists but uses ctx for cancellation and deadlines.
+func (s *StorageClient) FileExistsContext(ctx context.Context, key string) (bool, error) {
+	_, ok, err := s.headFile(ctx, key)
 	if err != nil {
-		if _, ok := err.(*NotFoundError); ok {
-			return false, nil
-		}
-		return false, err
+		return false, headFileError(err, key)
+	}
+	if ok {
+		return true, nil
+	}
+	if _, err := s.GetFileInfoContext(ctx, key); err != nil {
+		return false, headFileError(err, key)
 	}
 	return true, nil
 }
 
 // doRequest performs an HTTP request
-func (s *StorageClient) doRequest(method, path string, body interface{}) ([]byte, error) {
+func (s *StorageClient) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -254,32 +1275,94 @@ func (s *StorageClient) doRequest(method, path string, body interface{}) ([]byte
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
+	return s.doRawRequest(ctx, method, path, bodyReader, "application/json", -1)
+}
+
+// doRawRequest performs an HTTP request with an arbitrary body. A negative
+// length leaves the Content-Length to be inferred from body.
+func (s *StorageClient) doRawRequest(ctx context.Context, method, path string, body io.Reader, contentType string, length int64) ([]byte, error) {
+	respBody, _, err := s.send(ctx, method, path, body, contentType, length, nil)
+	return respBody, err
+}
+
+// send is like doRawRequest but also sets headers on the request and returns
+// the response headers. With a
+// retry policy, GET and HEAD requests are retried if body is nil or can be
+// rewound.
+func (s *StorageClient) send(ctx context.Context, method, path string, body io.Reader, contentType string, length int64, headers map[string]string) ([]byte, http.Header, error) {
+	attempts := 1
+	var rewind func() error
+	if s.retry.enabled() && s.retry.allowsMethod(method) {
+		if body != nil {
+			rewind = rewinder(body)
+		}
+		if body == nil || rewind != nil {
+			attempts = s.retry.MaxAttempts
+		}
+	}
+
+	var (
+		respBody []byte
+		header   http.Header
+	)
+	err := s.retryTransient(ctx, attempts, rewind, func() error {
+		var err error
+		respBody, header, err = s.sendOnce(ctx, method, path, body, contentType, length, headers)
+		return err
+	})
+	return respBody, header, err
+}
+
+// sendOnce performs a single attempt of send.
+func (s *StorageClient) sendOnce(ctx context.Context, method, path string, body io.Reader, contentType string, length int64, headers map[string]string) ([]byte, http.Header, error) {
+	path = rebase(path, DefaultStorageBasePath, s.basePath)
+	if s.bucket != "" {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + "bucket=" + url.QueryEscape(s.bucket)
+	}
 	url := s.projectURL + path
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if length >= 0 {
+		req.ContentLength = length
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	if token := s.UserToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-API-Key", s.getAPIKey())
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.getAPIKey())
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if err := intercept(s.interceptors, req); err != nil {
+		return nil, nil, err
+	}
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := httpClientFor(ctx, s.httpClient).Do(req)
 	if err != nil {
-		return nil, &StorageError{Err: err}
+		return nil, nil, &StorageError{Err: &NetworkError{Err: err}}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, parseStorageError(resp.StatusCode, respBody)
+		return nil, resp.Header, withRetryAfter(parseStorageError(resp.StatusCode, respBody), resp.Header)
 	}
 
-	return respBody, nil
+	return respBody, resp.Header, nil
 }
 
 // extractProjectSlug extracts the project slug from a project URL
@@ -316,114 +1399,383 @@ func (s *StorageClient) extractProjectSlug() string {
 }
 
 // GetFileUrl gets a presigned URL with full metadata (similar to Python's get_file_url)
+//
+// Deprecated: Use GetFileURLInfo, which returns a typed result.
 func (s *StorageClient) GetFileUrl(key string, expiresIn int) (map[string]interface{}, error) {
+	return s.GetFileUrlContext(context.Background(), key, expiresIn)
+}
+
+// GetFileUrlContext is like GetFileUrl but uses ctx for cancellation and deadlines.
+//
+// Deprecated: Use GetFileURLInfoContext, which returns a typed result.
+func (s *StorageClient) GetFileUrlContext(ctx context.Context, key string, expiresIn int) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := s.getFileURL(ctx, key, expiresIn, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetFileURLInfo gets a presigned URL along with the file's metadata
+func (s *StorageClient) GetFileURLInfo(key string, expiresIn int) (*FileURLInfo, error) {
+	return s.GetFileURLInfoContext(context.Background(), key, expiresIn)
+}
+
+// GetFileURLInfoContext is like GetFileURLInfo but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetFileURLInfoContext(ctx context.Context, key string, expiresIn int) (*FileURLInfo, error) {
+	var result FileURLInfo
+	if err := s.getFileURL(ctx, key, expiresIn, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *StorageClient) getFileURL(ctx context.Context, key string, expiresIn int, out interface{}) error {
 	projectSlug := s.extractProjectSlug()
 	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/files/%s/url?expires_in=%d", projectSlug, key, expiresIn)
-	resp, err := s.doRequest("GET", path, nil)
+	resp, err := s.doRequest(ctx, "GET", path, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return result, nil
+	return nil
 }
 
-// GetPresignedUrl generates a presigned URL for file operations
-func (s *StorageClient) GetPresignedUrl(key string, expiresIn int, operation string) (string, error) {
-	projectSlug := s.extractProjectSlug()
+// GetPublicURL builds the public URL of an object locally, without an API
+// call. It is much cheaper than GetPresignedUrl for assets served in web
+// pages, but only works for objects the backend has marked public; other
+// objects return 403 from this URL.
+//
+// By default the URL points at the storage API's public route,
+// <project URL><base path>/public/<bucket>/<key>, with the bucket left out
+// for the default bucket. If the project serves public objects from another
+// host, such as a CDN, configure it with SetPublicBaseURL. Each path segment
+// of key is escaped.
+func (s *StorageClient) GetPublicURL(key string) string {
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	escapedKey := strings.Join(segments, "/")
+
+	if s.publicBaseURL != "" {
+		return s.publicBaseURL + "/" + escapedKey
+	}
+
+	publicURL := strings.TrimRight(s.projectURL, "/") + rebase(DefaultStorageBasePath+"/public/", DefaultStorageBasePath, s.basePath)
+	if s.bucket != "" {
+		publicURL += url.PathEscape(s.bucket) + "/"
+	}
+	return publicURL + escapedKey
+}
+
+// GetPresignedUrl generates a presigned URL for file operations.
+// Download options such as WithAttachmentFilename apply to get_object URLs.
+// Use PresignRequest to choose the HTTP method and sign request headers.
+func (s *StorageClient) GetPresignedUrl(key string, expiresIn int, operation string, opts ...DownloadOption) (string, error) {
+	return s.GetPresignedUrlContext(context.Background(), key, expiresIn, operation, opts...)
+}
+
+// GetPresignedUrlContext is like GetPresignedUrl but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetPresignedUrlContext(ctx context.Context, key string, expiresIn int, operation string, opts ...DownloadOption) (string, error) {
+	var options downloadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	body := map[string]interface{}{
 		"file_key":   key,
 		"expires_in": expiresIn,
 		"operation":  operation,
 	}
+	params, err := options.responseParams()
+	if err != nil {
+		return "", err
+	}
+	for name, values := range params {
+		body[name] = values[0]
+	}
 
-	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/presigned-url", projectSlug)
-	resp, err := s.doRequest("POST", path, body)
+	result, err := s.presign(ctx, body)
 	if err != nil {
 		return "", err
 	}
+	return result.URL, nil
+}
 
-	var result struct {
-		URL string `json:"url"`
+// MaxPresignExpiry is the longest validity, in seconds, of a presigned URL.
+const MaxPresignExpiry = 7 * 24 * 60 * 60
+
+var presignOperations = map[string]string{
+	http.MethodGet:    "get_object",
+	http.MethodPut:    "put_object",
+	http.MethodDelete: "delete_object",
+}
+
+// PresignRequest generates a presigned URL for a GET, PUT or DELETE of key,
+// valid for expiresIn seconds (at most MaxPresignExpiry). signedHeaders are
+// included in the signature, e.g. Content-Type for a direct PUT upload; the
+// returned Headers must then be sent with the request. Download options
+// apply to GET only.
+//
+// Example:
+//
+//	req, err := storage.PresignRequest("PUT", "uploads/photo.jpg", 600,
+//	    map[string]string{"Content-Type": "image/jpeg"})
+func (s *StorageClient) PresignRequest(method, key string, expiresIn int, signedHeaders map[string]string, opts ...DownloadOption) (*PresignedRequest, error) {
+	return s.PresignRequestContext(context.Background(), method, key, expiresIn, signedHeaders, opts...)
+}
+
+// PresignRequestContext is like PresignRequest but uses ctx for cancellation and deadlines.
+func (s *StorageClient) PresignRequestContext(ctx context.Context, method, key string, expiresIn int, signedHeaders map[string]string, opts ...DownloadOption) (*PresignedRequest, error) {
+	method = strings.ToUpper(method)
+	operation, ok := presignOperations[method]
+	if !ok {
+		return nil, fmt.Errorf("unsupported presign method %q: must be GET, PUT or DELETE", method)
 	}
-	if err := json.Unmarshal(resp, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	if expiresIn <= 0 || expiresIn > MaxPresignExpiry {
+		return nil, fmt.Errorf("expiresIn must be between 1 and %d seconds", MaxPresignExpiry)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
 	}
 
-	return result.URL, nil
+	var options downloadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	body := map[string]interface{}{
+		"file_key":   key,
+		"expires_in": expiresIn,
+		"operation":  operation,
+		"method":     method,
+	}
+	if len(signedHeaders) > 0 {
+		body["headers"] = signedHeaders
+	}
+	if method == http.MethodGet {
+		params, err := options.responseParams()
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range params {
+			body[name] = values[0]
+		}
+	}
+
+	result, err := s.presign(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	if result.Method == "" {
+		result.Method = method
+	}
+	if result.Headers == nil && len(signedHeaders) > 0 {
+		result.Headers = make(map[string]string, len(signedHeaders))
+		for name, value := range signedHeaders {
+			result.Headers[name] = value
+		}
+	}
+	return result, nil
 }
 
-// GetStorageInfo gets S3 storage information for the project
-func (s *StorageClient) GetStorageInfo() (map[string]interface{}, error) {
+func (s *StorageClient) presign(ctx context.Context, body map[string]interface{}) (*PresignedRequest, error) {
 	projectSlug := s.extractProjectSlug()
-	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/info", projectSlug)
-	resp, err := s.doRequest("GET", path, nil)
+	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/presigned-url", projectSlug)
+	resp, err := s.doRequest(ctx, "POST", path, body)
 	if err != nil {
 		return nil, err
 	}
 
-	var result map[string]interface{}
+	var result PresignedRequest
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	return &result, nil
+}
+
+// GetStorageInfo gets S3 storage information for the project
+//
+// Deprecated: Use GetStorageDetails, which returns a typed result.
+func (s *StorageClient) GetStorageInfo() (map[string]interface{}, error) {
+	return s.GetStorageInfoContext(context.Background())
+}
+
+// GetStorageInfoContext is like GetStorageInfo but uses ctx for cancellation and deadlines.
+//
+// Deprecated: Use GetStorageDetailsContext, which returns a typed result.
+func (s *StorageClient) GetStorageInfoContext(ctx context.Context) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := s.getStorageInfo(ctx, &result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-// ProvisionStorage provisions S3 storage for the project
+// GetStorageDetails gets S3 storage information for the project
+func (s *StorageClient) GetStorageDetails() (*StorageInfo, error) {
+	return s.GetStorageDetailsContext(context.Background())
+}
+
+// GetStorageDetailsContext is like GetStorageDetails but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetStorageDetailsContext(ctx context.Context) (*StorageInfo, error) {
+	var result StorageInfo
+	if err := s.getStorageInfo(ctx, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *StorageClient) getStorageInfo(ctx context.Context, out interface{}) error {
+	projectSlug := s.extractProjectSlug()
+	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/info", projectSlug)
+	resp, err := s.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// ProvisionStorage provisions S3 storage for the project. If the project
+// already has storage the error is an *AlreadyProvisionedError, so re-runs
+// can treat it as success.
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, see SetServiceKey!
 // ⚠️ IMPORTANT: Save the credentials returned! They're only shown once.
-func (s *StorageClient) ProvisionStorage(region string) (map[string]interface{}, error) {
+func (s *StorageClient) ProvisionStorage(region string) (*ProvisionResult, error) {
+	return s.ProvisionStorageContext(context.Background(), region)
+}
+
+// ProvisionStorageContext is like ProvisionStorage but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ProvisionStorageContext(ctx context.Context, region string) (*ProvisionResult, error) {
+	admin, err := s.admin("provision storage")
+	if err != nil {
+		return nil, err
+	}
+
 	projectSlug := s.extractProjectSlug()
 	body := map[string]interface{}{
 		"region": region,
 	}
 
 	path := fmt.Sprintf("/api/v1/storage/s3/projects/%s/provision", projectSlug)
-	resp, err := s.doRequest("POST", path, body)
+	resp, err := admin.doRequest(ctx, "POST", path, body)
 	if err != nil {
+		var storageErr *StorageError
+		if errors.As(err, &storageErr) && storageErr.StatusCode == 409 {
+			return nil, &AlreadyProvisionedError{StorageError: *storageErr}
+		}
 		return nil, err
 	}
 
-	var result map[string]interface{}
+	var result ProvisionResult
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return result, nil
+	return &result, nil
 }
 
 // GetAvailableRegions gets list of available S3 regions with pricing
+//
+// Deprecated: Use ListRegions, which returns typed regions.
 func (s *StorageClient) GetAvailableRegions() ([]map[string]interface{}, error) {
-	resp, err := s.doRequest("GET", "/api/v1/storage/s3/regions", nil)
-	if err != nil {
-		return nil, err
-	}
+	return s.GetAvailableRegionsContext(context.Background())
+}
 
+// GetAvailableRegionsContext is like GetAvailableRegions but uses ctx for cancellation and deadlines.
+//
+// Deprecated: Use ListRegionsContext, which returns typed regions.
+func (s *StorageClient) GetAvailableRegionsContext(ctx context.Context) ([]map[string]interface{}, error) {
 	var result []map[string]interface{}
-	if err := json.Unmarshal(resp, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := s.getRegions(ctx, &result); err != nil {
+		return nil, err
 	}
+	return result, nil
+}
+
+// ListRegions gets the S3 regions storage can be provisioned in, with pricing
+// ⚠️ IMPORTANT: Requires SERVICE ROLE key, see SetServiceKey!
+func (s *StorageClient) ListRegions() ([]Region, error) {
+	return s.ListRegionsContext(context.Background())
+}
 
+// ListRegionsContext is like ListRegions but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListRegionsContext(ctx context.Context) ([]Region, error) {
+	var result []Region
+	if err := s.getRegions(ctx, &result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-// UploadFromPath uploads a file from local filesystem path
-func (s *StorageClient) UploadFromPath(filePath string, key string, contentType string, checkQuota *bool) (*FileUploadResult, error) {
-	// Read file from path
-	fileData, err := os.ReadFile(filePath)
+func (s *StorageClient) getRegions(ctx context.Context, out interface{}) error {
+	admin, err := s.admin("list regions")
+	if err != nil {
+		return err
+	}
+
+	resp, err := admin.doRequest(ctx, "GET", "/api/v1/storage/s3/regions", nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// UploadFromPath uploads a file from local filesystem path. The file is
+// streamed rather than read into memory. If contentType is empty it is
+// detected from the file's extension, then the key's, then by sniffing the
+// start of the file.
+func (s *StorageClient) UploadFromPath(filePath string, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	return s.UploadFromPathContext(context.Background(), filePath, key, contentType, checkQuota, opts...)
+}
+
+// UploadFromPathContext is like UploadFromPath but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadFromPathContext(ctx context.Context, filePath string, key string, contentType string, checkQuota *bool, opts ...UploadOption) (*FileUploadResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("failed to read file: %s is a directory", filePath)
+	}
 
-	return s.Upload(fileData, key, contentType, checkQuota)
+	if contentType == "" {
+		contentType = detectContentType(filePath, nil)
+	}
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, _ := file.ReadAt(head, 0)
+		contentType = detectContentType(key, head[:n])
+	}
+
+	return s.UploadStreamContext(ctx, file, info.Size(), key, contentType, checkQuota, opts...)
 }
 
-// formatBytes formats bytes to human-readable string
-func formatBytes(bytes int64) string {
+// FormatBytes formats a byte count for display using binary units, e.g.
+// 1536 becomes "1.50 KB". ParseBytes reads the same notation back.
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -436,3 +1788,48 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+var byteUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+	"P": 1 << 50,
+	"E": 1 << 60,
+}
+
+// ParseBytes parses a human-entered size such as "512", "5MB", "1.5 GB" or
+// "10KiB". Units are binary (1 KB = 1024 bytes), like FormatBytes, and are
+// case-insensitive.
+func ParseBytes(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	end := 0
+	for end < len(trimmed) && (trimmed[end] >= '0' && trimmed[end] <= '9' || trimmed[end] == '.') {
+		end++
+	}
+	if end == 0 {
+		return 0, fmt.Errorf("invalid size %q: missing number", value)
+	}
+
+	number, err := strconv.ParseFloat(trimmed[:end], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", value, err)
+	}
+
+	unit := strings.ToUpper(strings.TrimSpace(trimmed[end:]))
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "IB"), "B")
+	if unit == "" && strings.HasSuffix(strings.ToUpper(trimmed), "IB") {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", value)
+	}
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", value)
+	}
+
+	bytes := number * float64(multiplier)
+	if bytes >= float64(math.MaxInt64) {
+		return 0, fmt.Errorf("invalid size %q: too large", value)
+	}
+	return int64(bytes), nil
+}
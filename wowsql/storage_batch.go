@@ -0,0 +1,151 @@
+package WOWSQL
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// UploadItem describes one file for UploadBatch. Set either FileData or Path.
+type UploadItem struct {
+	FileData    []byte
+	Path        string
+	Key         string
+	ContentType string
+}
+
+// BatchUploadResult is the outcome of uploading one UploadItem.
+type BatchUploadResult struct {
+	Key    string
+	Result *FileUploadResult
+	Err    error
+}
+
+// UploadBatch uploads items using up to concurrency parallel workers. A failed
+// item does not stop the others; results are returned in the same order as
+// items, and the returned error joins every per-item failure (nil if all
+// succeeded).
+func (s *StorageClient) UploadBatch(items []UploadItem, concurrency int) ([]BatchUploadResult, error) {
+	return s.UploadBatchContext(context.Background(), items, concurrency)
+}
+
+// UploadBatchContext is like UploadBatch but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadBatchContext(ctx context.Context, items []UploadItem, concurrency int) ([]BatchUploadResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+
+	results := make([]BatchUploadResult, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.uploadItem(ctx, items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Key, r.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("%d of %d uploads failed: %w", len(errs), len(items), errors.Join(errs...))
+	}
+	return results, nil
+}
+
+func (s *StorageClient) uploadItem(ctx context.Context, item UploadItem) BatchUploadResult {
+	result := BatchUploadResult{Key: item.Key}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	switch {
+	case item.FileData != nil:
+		result.Result, result.Err = s.UploadContext(ctx, item.FileData, item.Key, item.ContentType, nil)
+	case item.Path != "":
+		result.Result, result.Err = s.UploadFromPathContext(ctx, item.Path, item.Key, item.ContentType, nil)
+	default:
+		result.Err = fmt.Errorf("either FileData or Path is required")
+	}
+	return result
+}
+
+// FilesInfoConcurrency is how many GetFileInfo requests GetFilesInfo runs at
+// once.
+const FilesInfoConcurrency = 8
+
+// GetFilesInfo looks up the metadata of several files concurrently. Keys that
+// do not exist are omitted from the result rather than reported as errors.
+// Other failures do not stop the remaining lookups; the returned error joins
+// them, and the map still holds every file that was found.
+func (s *StorageClient) GetFilesInfo(keys []string) (map[string]*StorageFile, error) {
+	return s.GetFilesInfoContext(context.Background(), keys)
+}
+
+// GetFilesInfoContext is like GetFilesInfo but uses ctx for cancellation and deadlines.
+func (s *StorageClient) GetFilesInfoContext(ctx context.Context, keys []string) (map[string]*StorageFile, error) {
+	concurrency := FilesInfoConcurrency
+	if concurrency > len(keys) {
+		concurrency = len(keys)
+	}
+
+	files := make([]*StorageFile, len(keys))
+	errs := make([]error, len(keys))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				files[i], errs[i] = s.GetFileInfoContext(ctx, keys[i])
+			}
+		}()
+	}
+
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := make(map[string]*StorageFile, len(keys))
+	var failed []error
+	for i, key := range keys {
+		switch {
+		case errs[i] == nil:
+			result[key] = files[i]
+		case errors.Is(errs[i], ErrNotFound):
+		default:
+			failed = append(failed, fmt.Errorf("%s: %w", key, errs[i]))
+		}
+	}
+	if len(failed) > 0 {
+		return result, fmt.Errorf("%d of %d lookups failed: %w", len(failed), len(keys), errors.Join(failed...))
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package WOWSQL
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ChecksumAlgorithm selects the hash used to verify transferred data.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumMD5 is compared against the ETag returned by the server.
+	ChecksumMD5 ChecksumAlgorithm = "md5"
+	// ChecksumSHA256 is compared against the checksum returned by the server.
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+)
+
+func (a ChecksumAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", string(a))
+}
+
+// WithChecksum hashes the file data while it is uploaded and compares the
+// result with the ETag (MD5) or checksum (SHA-256) returned by the server.
+// A mismatch is reported as a *ChecksumMismatchError.
+func WithChecksum(algorithm ChecksumAlgorithm) UploadOption {
+	return func(o *uploadOptions) {
+		o.checksum = algorithm
+	}
+}
+
+// WithExpectedChecksum verifies the downloaded content against a hex-encoded
+// hash. A mismatch is reported as a *ChecksumMismatchError once the
+// download has finished.
+func WithExpectedChecksum(algorithm ChecksumAlgorithm, expected string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.checksum = algorithm
+		o.expectedChecksum = expected
+	}
+}
+
+// verifyUploadChecksum compares the locally computed hash with the one
+// reported for the uploaded file.
+func verifyUploadChecksum(algorithm ChecksumAlgorithm, h hash.Hash, result *FileUploadResult) error {
+	expected := result.Checksum
+	if algorithm == ChecksumMD5 {
+		expected = result.ETag
+	}
+	expected = strings.Trim(expected, `"`)
+	if expected == "" {
+		return &StorageError{Message: fmt.Sprintf("server did not return a %s checksum for %s", algorithm, result.Key)}
+	}
+	return compareChecksum(algorithm, result.Key, expected, h)
+}
+
+func compareChecksum(algorithm ChecksumAlgorithm, key, expected string, h hash.Hash) error {
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(expected, actual) {
+		return &ChecksumMismatchError{
+			Key:       key,
+			Algorithm: algorithm,
+			Expected:  expected,
+			Actual:    actual,
+		}
+	}
+	return nil
+}
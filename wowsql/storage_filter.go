@@ -0,0 +1,133 @@
+package WOWSQL
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileFilter selects files by content type and size. Zero values match
+// everything.
+type FileFilter struct {
+	// ContentType matches files whose content type starts with it, so
+	// "image/" matches every image.
+	ContentType string
+	// MinSize and MaxSize bound the file size in bytes (inclusive). A zero
+	// MaxSize means no upper bound.
+	MinSize int64
+	MaxSize int64
+}
+
+// Match reports whether file satisfies the filter.
+func (f FileFilter) Match(file StorageFile) bool {
+	if f.ContentType != "" {
+		if file.ContentType == nil || !strings.HasPrefix(*file.ContentType, f.ContentType) {
+			return false
+		}
+	}
+	if file.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && file.Size > f.MaxSize {
+		return false
+	}
+	return true
+}
+
+// FileSortField selects the StorageFile field SortFiles orders by.
+type FileSortField int
+
+const (
+	SortByKey FileSortField = iota
+	SortBySize
+	SortByLastModified
+)
+
+// FilterFiles returns the files that match filter, preserving their order.
+func FilterFiles(files []StorageFile, filter FileFilter) []StorageFile {
+	var matched []StorageFile
+	for _, file := range files {
+		if filter.Match(file) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+// SortFiles sorts files in place by the given field, ascending unless
+// descending is set. Ties are broken by key.
+func SortFiles(files []StorageFile, by FileSortField, descending bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if descending {
+			a, b = b, a
+		}
+		switch by {
+		case SortBySize:
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case SortByLastModified:
+			ta, tb := parseLastModified(a.LastModified), parseLastModified(b.LastModified)
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		}
+		return a.Key < b.Key
+	})
+}
+
+func parseLastModified(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, time.RFC1123, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ListFilesFiltered lists every file under prefix that matches filter.
+//
+// The filter is sent to the server as the content_type, min_size and
+// max_size query parameters so it can be applied server-side, and is applied
+// again to each returned page, so the result is correct either way.
+func (s *StorageClient) ListFilesFiltered(prefix string, filter FileFilter) ([]StorageFile, error) {
+	return s.ListFilesFilteredContext(context.Background(), prefix, filter)
+}
+
+// ListFilesFilteredContext is like ListFilesFiltered but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListFilesFilteredContext(ctx context.Context, prefix string, filter FileFilter) ([]StorageFile, error) {
+	query := url.Values{}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if filter.ContentType != "" {
+		query.Set("content_type", filter.ContentType)
+	}
+	if filter.MinSize > 0 {
+		query.Set("min_size", fmt.Sprint(filter.MinSize))
+	}
+	if filter.MaxSize > 0 {
+		query.Set("max_size", fmt.Sprint(filter.MaxSize))
+	}
+
+	var matched []StorageFile
+	cursor := ""
+	for {
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		files, next, err := s.listPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, FilterFiles(files, filter)...)
+		if next == "" || next == cursor {
+			return matched, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,94 @@
+package WOWSQL
+
+import (
+	"context"
+)
+
+// FileIterator walks a file listing one page at a time, so only the current
+// page is held in memory. Use it like bufio.Scanner:
+//
+//	it := storage.ListFilesIter("logs/")
+//	for it.Next() {
+//	    file := it.File()
+//	    // ...
+//	}
+//	if err := it.Err(); err != nil {
+//	    // handle error
+//	}
+//
+// Stopping early is fine; there is nothing to close.
+type FileIterator struct {
+	ctx    context.Context
+	s      *StorageClient
+	prefix string
+
+	page   []StorageFile
+	index  int
+	cursor string
+	done   bool
+	err    error
+}
+
+// ListFilesIter returns an iterator over every file under prefix. The next
+// page is fetched lazily when the current one is exhausted.
+func (s *StorageClient) ListFilesIter(prefix string) *FileIterator {
+	return s.ListFilesIterContext(context.Background(), prefix)
+}
+
+// ListFilesIterContext is like ListFilesIter but uses ctx for cancellation and deadlines.
+func (s *StorageClient) ListFilesIterContext(ctx context.Context, prefix string) *FileIterator {
+	return &FileIterator{ctx: ctx, s: s, prefix: prefix, index: -1}
+}
+
+// Next advances to the next file, fetching another page if needed. It
+// returns false when the listing is exhausted or a request failed; check Err
+// to tell the two apart.
+func (it *FileIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if !it.fetch() {
+			return false
+		}
+	}
+	return true
+}
+
+// nextPage skips the rest of the current page and returns the next one
+// whole. It returns false when the listing is exhausted or a request failed.
+func (it *FileIterator) nextPage() ([]StorageFile, bool) {
+	if it.err != nil || !it.fetch() {
+		return nil, false
+	}
+	it.index = len(it.page)
+	return it.page, true
+}
+
+// fetch loads the next page, if there is one.
+func (it *FileIterator) fetch() bool {
+	if it.done {
+		return false
+	}
+	files, next, err := it.s.ListFilesPagedContext(it.ctx, it.prefix, 0, it.cursor)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if next == "" || next == it.cursor {
+		it.done = true
+	}
+	it.page, it.index, it.cursor = files, 0, next
+	return true
+}
+
+// File returns the current file. It is only valid after Next returned true.
+func (it *FileIterator) File() StorageFile {
+	return it.page[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *FileIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,200 @@
+package WOWSQL
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// MinMultipartPartSize is the smallest allowed size for every part of a
+// multipart upload except the last one.
+const MinMultipartPartSize = 5 * 1024 * 1024
+
+// DefaultMultipartPartSize is the part size used by UploadMultipart when none is given.
+const DefaultMultipartPartSize = 8 * 1024 * 1024
+
+// PartETag identifies an uploaded part when completing a multipart upload.
+type PartETag struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// InitMultipartUpload starts a multipart upload for key and returns its ID.
+// Parts can then be sent (and retried) independently with UploadPart.
+func (s *StorageClient) InitMultipartUpload(key, contentType string) (string, error) {
+	return s.InitMultipartUploadContext(context.Background(), key, contentType)
+}
+
+// InitMultipartUploadContext is like InitMultipartUpload but uses ctx for cancellation and deadlines.
+func (s *StorageClient) InitMultipartUploadContext(ctx context.Context, key, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = detectContentType(key, nil)
+	}
+
+	body := map[string]interface{}{
+		"key": key,
+	}
+	if contentType != "" {
+		body["content_type"] = contentType
+	}
+
+	resp, err := s.doRequest(ctx, "POST", "/api/v1/storage/multipart/init", body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		UploadID string `json:"upload_id"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", &StorageError{Message: "server did not return an upload id"}
+	}
+
+	return result.UploadID, nil
+}
+
+// UploadPart uploads a single part (numbered from 1) and returns its ETag.
+// With WithRetry, transient failures are retried.
+func (s *StorageClient) UploadPart(uploadID string, partNumber int, data []byte) (string, error) {
+	return s.UploadPartContext(context.Background(), uploadID, partNumber, data)
+}
+
+// UploadPartContext is like UploadPart but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadPartContext(ctx context.Context, uploadID string, partNumber int, data []byte) (string, error) {
+	if partNumber < 1 {
+		return "", fmt.Errorf("partNumber must be at least 1")
+	}
+
+	// Re-sending a part replaces it, so with a retry policy transient
+	// failures are retried even though PUT is not retried in general.
+	attempts := 1
+	if s.retry.enabled() {
+		attempts = s.retry.MaxAttempts
+	}
+
+	path := fmt.Sprintf("/api/v1/storage/multipart/%s/parts/%d", url.PathEscape(uploadID), partNumber)
+	var resp []byte
+	err := s.retryTransient(ctx, attempts, nil, func() error {
+		var err error
+		resp, _, err = s.sendOnce(ctx, "PUT", path, bytes.NewReader(data), "application/octet-stream", int64(len(data)), nil)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ETag string `json:"etag"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.ETag, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the final object.
+func (s *StorageClient) CompleteMultipartUpload(uploadID string, parts []PartETag) (*FileUploadResult, error) {
+	return s.CompleteMultipartUploadContext(context.Background(), uploadID, parts)
+}
+
+// CompleteMultipartUploadContext is like CompleteMultipartUpload but uses ctx for cancellation and deadlines.
+func (s *StorageClient) CompleteMultipartUploadContext(ctx context.Context, uploadID string, parts []PartETag) (*FileUploadResult, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("at least one part is required")
+	}
+
+	body := map[string]interface{}{
+		"parts": parts,
+	}
+
+	path := fmt.Sprintf("/api/v1/storage/multipart/%s/complete", url.PathEscape(uploadID))
+	resp, err := s.doRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result FileUploadResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// AbortMultipartUpload cancels a multipart upload and discards uploaded parts.
+func (s *StorageClient) AbortMultipartUpload(uploadID string) error {
+	return s.AbortMultipartUploadContext(context.Background(), uploadID)
+}
+
+// AbortMultipartUploadContext is like AbortMultipartUpload but uses ctx for cancellation and deadlines.
+func (s *StorageClient) AbortMultipartUploadContext(ctx context.Context, uploadID string) error {
+	path := fmt.Sprintf("/api/v1/storage/multipart/%s", url.PathEscape(uploadID))
+	_, err := s.doRequest(ctx, "DELETE", path, nil)
+	return err
+}
+
+// UploadMultipart uploads everything read from reader as a multipart upload,
+// splitting it into parts of partSize bytes (DefaultMultipartPartSize if 0).
+// Parts are retried as with UploadPart; if the upload still fails it is
+// aborted.
+func (s *StorageClient) UploadMultipart(reader io.Reader, key, contentType string, partSize int64) (*FileUploadResult, error) {
+	return s.UploadMultipartContext(context.Background(), reader, key, contentType, partSize)
+}
+
+// UploadMultipartContext is like UploadMultipart but uses ctx for cancellation and deadlines.
+func (s *StorageClient) UploadMultipartContext(ctx context.Context, reader io.Reader, key, contentType string, partSize int64) (*FileUploadResult, error) {
+	if partSize == 0 {
+		partSize = DefaultMultipartPartSize
+	}
+	if partSize < MinMultipartPartSize {
+		return nil, fmt.Errorf("partSize must be at least %s", FormatBytes(MinMultipartPartSize))
+	}
+
+	uploadID, err := s.InitMultipartUploadContext(ctx, key, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.uploadParts(ctx, uploadID, reader, partSize)
+	if err != nil {
+		// Use a fresh context so the abort still goes out if ctx was cancelled.
+		_ = s.AbortMultipartUploadContext(context.Background(), uploadID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *StorageClient) uploadParts(ctx context.Context, uploadID string, reader io.Reader, partSize int64) (*FileUploadResult, error) {
+	var parts []PartETag
+	buf := make([]byte, partSize)
+	for partNumber := 1; ; partNumber++ {
+		n, readErr := io.ReadFull(reader, buf)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to read part %d: %w", partNumber, readErr)
+		}
+		// An empty reader still produces a single empty part.
+		if n == 0 && len(parts) > 0 {
+			break
+		}
+
+		etag, err := s.UploadPartContext(ctx, uploadID, partNumber, buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+		}
+		parts = append(parts, PartETag{PartNumber: partNumber, ETag: etag})
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	return s.CompleteMultipartUploadContext(ctx, uploadID, parts)
+}
@@ -0,0 +1,162 @@
+package WOWSQL
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// multipartServer records the parts it receives and the parts listed when
+// the upload is completed.
+type multipartServer struct {
+	mu        sync.Mutex
+	parts     map[int][]byte
+	completed []PartETag
+	aborted   bool
+	// failPart fails with failStatus (500 if zero), the first failTimes
+	// attempts only if failTimes is set.
+	failPart   int
+	failStatus int
+	failTimes  int
+	attempts   int
+}
+
+func (m *multipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/multipart/init"):
+		fmt.Fprint(w, `{"upload_id":"up-1"}`)
+	case r.Method == "PUT":
+		var number int
+		fmt.Sscanf(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], "%d", &number)
+		if number == m.failPart {
+			m.attempts++
+			if m.failTimes == 0 || m.attempts <= m.failTimes {
+				status := m.failStatus
+				if status == 0 {
+					status = http.StatusInternalServerError
+				}
+				w.WriteHeader(status)
+				return
+			}
+		}
+		data, _ := io.ReadAll(r.Body)
+		m.parts[number] = data
+		fmt.Fprintf(w, `{"etag":"etag-%d"}`, number)
+	case strings.HasSuffix(r.URL.Path, "/complete"):
+		var body struct {
+			Parts []PartETag `json:"parts"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		m.completed = body.Parts
+		fmt.Fprint(w, `{"key":"big.bin","size":1}`)
+	case r.Method == "DELETE":
+		m.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestUploadMultipartSplitsParts(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		wantSizes []int
+	}{
+		{"remainder", 2*MinMultipartPartSize + 3, []int{MinMultipartPartSize, MinMultipartPartSize, 3}},
+		{"exact multiple", 2 * MinMultipartPartSize, []int{MinMultipartPartSize, MinMultipartPartSize}},
+		{"smaller than a part", 10, []int{10}},
+		{"empty", 0, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &multipartServer{parts: map[int][]byte{}}
+			server := httptest.NewServer(backend)
+			defer server.Close()
+
+			data := bytes.Repeat([]byte("abcdefg"), tt.size/7+1)[:tt.size]
+			client := NewStorageClient(server.URL, "wowsql_service_test")
+			if _, err := client.UploadMultipart(bytes.NewReader(data), "big.bin", "", MinMultipartPartSize); err != nil {
+				t.Fatalf("UploadMultipart: %v", err)
+			}
+
+			if len(backend.completed) != len(tt.wantSizes) {
+				t.Fatalf("completed with %d parts, want %d", len(backend.completed), len(tt.wantSizes))
+			}
+			var joined []byte
+			for i, want := range tt.wantSizes {
+				part := backend.completed[i]
+				if part.PartNumber != i+1 || part.ETag != fmt.Sprintf("etag-%d", i+1) {
+					t.Errorf("part %d listed as %+v", i+1, part)
+				}
+				if got := len(backend.parts[i+1]); got != want {
+					t.Errorf("part %d has %d bytes, want %d", i+1, got, want)
+				}
+				joined = append(joined, backend.parts[i+1]...)
+			}
+			if !bytes.Equal(joined, data) {
+				t.Error("parts do not add up to the uploaded data")
+			}
+		})
+	}
+}
+
+func TestUploadMultipartAbortsOnFailure(t *testing.T) {
+	backend := &multipartServer{parts: map[int][]byte{}, failPart: 2}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client := NewStorageClient(server.URL, "wowsql_service_test")
+	data := make([]byte, MinMultipartPartSize+1)
+	if _, err := client.UploadMultipart(bytes.NewReader(data), "big.bin", "", MinMultipartPartSize); err == nil {
+		t.Fatal("UploadMultipart succeeded, want an error")
+	}
+	if !backend.aborted || backend.completed != nil {
+		t.Errorf("aborted = %v, completed = %v; want an aborted, uncompleted upload", backend.aborted, backend.completed)
+	}
+	if _, err := client.UploadMultipart(bytes.NewReader(data), "big.bin", "", 1024); err == nil {
+		t.Error("UploadMultipart accepted a part size below MinMultipartPartSize")
+	}
+}
+
+func TestUploadMultipartRetriesTransientPartFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		retry        bool
+		failStatus   int
+		failTimes    int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"no retry policy", false, http.StatusServiceUnavailable, 1, 1, true},
+		{"transient", true, http.StatusServiceUnavailable, 2, 3, false},
+		{"transient exhausted", true, http.StatusBadGateway, 0, 3, true},
+		{"permanent", true, http.StatusBadRequest, 0, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &multipartServer{parts: map[int][]byte{}, failPart: 1, failStatus: tt.failStatus, failTimes: tt.failTimes}
+			server := httptest.NewServer(backend)
+			defer server.Close()
+
+			client := NewStorageClient(server.URL, "wowsql_service_test")
+			if tt.retry {
+				client.WithRetry(3, 0)
+			}
+			_, err := client.UploadMultipart(bytes.NewReader([]byte("data")), "big.bin", "", MinMultipartPartSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UploadMultipart error = %v, want error: %v", err, tt.wantErr)
+			}
+			if backend.attempts != tt.wantAttempts {
+				t.Errorf("part sent %d times, want %d", backend.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
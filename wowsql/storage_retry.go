@@ -0,0 +1,78 @@
+package WOWSQL
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// WithRetry enables retries with exponential backoff and jitter for
+// transient failures (network errors, 429 and 5xx responses). GET and HEAD
+// requests are retried, and so are uploads whose data can be read again:
+// Upload, UploadFromPath and UploadStream with a reader that implements
+// io.Seeker, such as an *os.File, and multipart upload parts. UploadStream
+// with any other reader is sent once, since the data already consumed cannot
+// be replayed. Progress
+// callbacks start again from zero on each retry.
+//
+// Bucket clients created afterwards share the policy. Call it before the
+// client is shared between goroutines.
+func (s *StorageClient) WithRetry(maxAttempts int, baseDelay time.Duration) *StorageClient {
+	s.retry = &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+	}
+	return s
+}
+
+// retryTransient calls fn until it succeeds, fails permanently or runs out of
+// attempts, waiting between attempts. rewind, if set, is called before each
+// retry to reset the request body.
+func (s *StorageClient) retryTransient(ctx context.Context, attempts int, rewind func() error, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !isRetryableStorageError(err) {
+			return err
+		}
+
+		delay := s.retry.backoff(attempt)
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > delay {
+			delay = rateLimitErr.RetryAfter
+		}
+		if sleepContext(ctx, delay) != nil {
+			return err
+		}
+		if rewind != nil && rewind() != nil {
+			return err
+		}
+	}
+}
+
+// rewinder returns a function that seeks r back to its current offset, or
+// nil if r cannot be read again.
+func rewinder(r io.Reader) func() error {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() error {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+}
+
+// isRetryableStorageError is like isRetryableError but also treats every 5xx
+// status as transient, since storage backends surface S3 500s directly.
+func isRetryableStorageError(err error) bool {
+	if isRetryableError(err) {
+		return true
+	}
+	var apiErr *WOWSQLError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
@@ -0,0 +1,182 @@
+package WOWSQL
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1.00 KB",
+		1536:                   "1.50 KB",
+		5 * 1024 * 1024:        "5.00 MB",
+		3 * 1024 * 1024 * 1024: "3.00 GB",
+		1 << 40:                "1.00 TB",
+	}
+	for bytes, want := range tests {
+		if got := FormatBytes(bytes); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"1KB", 1024},
+		{"1 kb", 1024},
+		{"10KiB", 10240},
+		{"5MB", 5 << 20},
+		{"1.5 GB", 3 << 29},
+		{"2T", 2 << 40},
+		{" 1.50 KB ", 1536},
+	}
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBytes(%q) = %d, %v; want %d", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "MB", "5 XB", "5iB", "1.2.3KB", "9999999EB", "-5MB"} {
+		if _, err := ParseBytes(value); err == nil {
+			t.Errorf("ParseBytes(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseBytesRoundTrip(t *testing.T) {
+	for _, bytes := range []int64{1536, 5 << 20, 3 << 30} {
+		parsed, err := ParseBytes(FormatBytes(bytes))
+		if err != nil || parsed != bytes {
+			t.Errorf("ParseBytes(FormatBytes(%d)) = %d, %v", bytes, parsed, err)
+		}
+	}
+}
+
+func TestGetPublicURL(t *testing.T) {
+	client := NewStorageClient("https://myproject.wowsql.com", "wowsql_anon_test")
+	tests := []struct {
+		client *StorageClient
+		key    string
+		want   string
+	}{
+		{client, "images/logo.png", "https://myproject.wowsql.com/api/v1/storage/public/images/logo.png"},
+		{client, "/a b/c#1.png", "https://myproject.wowsql.com/api/v1/storage/public/a%20b/c%231.png"},
+		{client.Bucket("team assets"), "logo.png", "https://myproject.wowsql.com/api/v1/storage/public/team%20assets/logo.png"},
+	}
+	for _, tt := range tests {
+		if got := tt.client.GetPublicURL(tt.key); got != tt.want {
+			t.Errorf("GetPublicURL(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	client.SetPublicBaseURL("https://cdn.example.com/assets/")
+	if got, want := client.GetPublicURL("a b.png"), "https://cdn.example.com/assets/a%20b.png"; got != want {
+		t.Errorf("GetPublicURL with a public base URL = %q, want %q", got, want)
+	}
+}
+
+func TestDeleteByPrefixDeletesPageByPage(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"files":[{"key":"logs/1"},{"key":"logs/2"}],"next_cursor":"c1"}`,
+		"c1": `{"files":[],"next_cursor":"c2"}`,
+		"c2": `{"files":[{"key":"logs/3"}]}`,
+	}
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/storage/list":
+			if r.URL.Query().Get("prefix") != "logs/" {
+				t.Errorf("listed prefix %q", r.URL.Query().Get("prefix"))
+			}
+			w.Write([]byte(pages[r.URL.Query().Get("cursor")]))
+		case "/api/v1/storage/delete-batch":
+			var body struct {
+				Keys []string `json:"keys"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			batches = append(batches, body.Keys)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	deleted, err := NewStorageClient(server.URL, "wowsql_service_test").DeleteByPrefix("logs/")
+	if err != nil || deleted != 3 {
+		t.Fatalf("DeleteByPrefix = %d, %v; want 3, nil", deleted, err)
+	}
+	if want := [][]string{{"logs/1", "logs/2"}, {"logs/3"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("deleted in batches %v, want %v", batches, want)
+	}
+
+	if _, err := NewStorageClient(server.URL, "wowsql_service_test").DeleteByPrefix(""); err == nil {
+		t.Error("DeleteByPrefix accepted an empty prefix")
+	}
+}
+
+func TestFileExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		headStatus int
+		infoStatus int
+		want       bool
+		wantInfo   bool
+	}{
+		{"head ok without size", http.StatusOK, http.StatusInternalServerError, true, false},
+		{"head not found", http.StatusNotFound, http.StatusOK, false, false},
+		{"head not allowed", http.StatusMethodNotAllowed, http.StatusOK, true, true},
+		{"head not implemented", http.StatusNotImplemented, http.StatusNotFound, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var infoCalled bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.WriteHeader(tt.headStatus)
+					return
+				}
+				infoCalled = true
+				w.WriteHeader(tt.infoStatus)
+				w.Write([]byte(`{"key":"a.txt","size":3}`))
+			}))
+			defer server.Close()
+
+			exists, err := NewStorageClient(server.URL, "wowsql_service_test").FileExists("a.txt")
+			if err != nil || exists != tt.want {
+				t.Errorf("FileExists = %v, %v; want %v, nil", exists, err, tt.want)
+			}
+			if infoCalled != tt.wantInfo {
+				t.Errorf("GetFileInfo called = %v, want %v", infoCalled, tt.wantInfo)
+			}
+		})
+	}
+}
+
+func TestNewStorageClientWithConfig(t *testing.T) {
+	client := NewStorageClientWithConfig(StorageConfig{ProjectURL: "myproject", BaseDomain: "example.dev", Secure: true})
+	if want := "https://myproject.example.dev"; client.projectURL != want {
+		t.Errorf("project URL %q, want %q", client.projectURL, want)
+	}
+	if !client.AutoCheckQuota() {
+		t.Error("quota checks should be on unless disabled")
+	}
+
+	client = NewStorageClientWithOptions("myproject", "wowsql_service_test", time.Minute, false)
+	if want := "https://myproject.wowsql.com"; client.projectURL != want {
+		t.Errorf("project URL %q, want %q", client.projectURL, want)
+	}
+	if client.AutoCheckQuota() {
+		t.Error("quota checks should be off")
+	}
+}
@@ -0,0 +1,82 @@
+package WOWSQL
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Image formats accepted by TransformOptions.Format
+const (
+	ImageFormatWebP = "webp"
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatAVIF = "avif"
+)
+
+// Resize modes accepted by TransformOptions.Resize
+const (
+	// ResizeCover fills the box, cropping whatever does not fit.
+	ResizeCover = "cover"
+	// ResizeContain fits the whole image inside the box.
+	ResizeContain = "contain"
+	// ResizeFill stretches the image to the box.
+	ResizeFill = "fill"
+)
+
+// TransformOptions asks the server to resize or re-encode an image before
+// serving it. Zero values leave the corresponding property unchanged.
+type TransformOptions struct {
+	Width   int
+	Height  int
+	Quality int    // 1-100
+	Format  string // webp, jpeg, png, avif
+	Resize  string // cover, contain, fill
+}
+
+// WithTransform makes a presigned download URL serve a transformed image,
+// e.g. a thumbnail. The options are validated when the URL is requested.
+func WithTransform(transform TransformOptions) DownloadOption {
+	return func(o *downloadOptions) {
+		o.transform = &transform
+	}
+}
+
+func (t *TransformOptions) validate() error {
+	if t.Width < 0 || t.Height < 0 {
+		return fmt.Errorf("transform width and height must be positive")
+	}
+	if t.Quality < 0 || t.Quality > 100 {
+		return fmt.Errorf("transform quality must be between 1 and 100")
+	}
+	switch t.Format {
+	case "", ImageFormatWebP, ImageFormatJPEG, ImageFormatPNG, ImageFormatAVIF:
+	default:
+		return fmt.Errorf("unsupported transform format %q", t.Format)
+	}
+	switch t.Resize {
+	case "", ResizeCover, ResizeContain, ResizeFill:
+	default:
+		return fmt.Errorf("unsupported resize mode %q", t.Resize)
+	}
+	return nil
+}
+
+// params adds the transform query parameters understood by the server.
+func (t *TransformOptions) params(values url.Values) {
+	if t.Width > 0 {
+		values.Set("width", strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		values.Set("height", strconv.Itoa(t.Height))
+	}
+	if t.Quality > 0 {
+		values.Set("quality", strconv.Itoa(t.Quality))
+	}
+	if t.Format != "" {
+		values.Set("format", t.Format)
+	}
+	if t.Resize != "" {
+		values.Set("resize", t.Resize)
+	}
+}
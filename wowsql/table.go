@@ -1,6 +1,7 @@
 package WOWSQL
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -33,7 +34,12 @@ func (t *Table) GetByID(id interface{}) *QueryBuilder {
 
 // Insert inserts a new record
 func (t *Table) Insert(data map[string]interface{}) (*CreateResponse, error) {
-	resp, err := t.client.doRequest("POST", fmt.Sprintf("/api/v1/tables/%s", t.tableName), data)
+	return t.InsertContext(context.Background(), data)
+}
+
+// InsertContext is like Insert but uses ctx for cancellation and deadlines.
+func (t *Table) InsertContext(ctx context.Context, data map[string]interface{}) (*CreateResponse, error) {
+	resp, err := t.client.doRequest(ctx, "POST", fmt.Sprintf("/api/v1/tables/%s", t.tableName), data)
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +54,22 @@ func (t *Table) Insert(data map[string]interface{}) (*CreateResponse, error) {
 
 // UpdateByID updates a record by ID
 func (t *Table) UpdateByID(id interface{}, data map[string]interface{}) (*UpdateResponse, error) {
-	return t.Where().Eq("id", id).Update(data)
+	return t.UpdateByIDContext(context.Background(), id, data)
+}
+
+// UpdateByIDContext is like UpdateByID but uses ctx for cancellation and deadlines.
+func (t *Table) UpdateByIDContext(ctx context.Context, id interface{}, data map[string]interface{}) (*UpdateResponse, error) {
+	return t.Where().Eq("id", id).UpdateContext(ctx, data)
 }
 
 // DeleteByID deletes a record by ID
 func (t *Table) DeleteByID(id interface{}) (*DeleteResponse, error) {
-	return t.Where().Eq("id", id).Delete()
+	return t.DeleteByIDContext(context.Background(), id)
+}
+
+// DeleteByIDContext is like DeleteByID but uses ctx for cancellation and deadlines.
+func (t *Table) DeleteByIDContext(ctx context.Context, id interface{}) (*DeleteResponse, error) {
+	return t.Where().Eq("id", id).DeleteContext(ctx)
 }
 
 // Where creates a new QueryBuilder for filtered operations
@@ -0,0 +1,18 @@
+package WOWSQL
+
+import (
+	"context"
+	"net/http"
+)
+
+// httpClientFor returns the client to send a request with ctx. When ctx has
+// its own deadline, that deadline replaces the client's fixed timeout, so a
+// long upload can be given more time and a quick lookup less.
+func httpClientFor(ctx context.Context, client *http.Client) *http.Client {
+	if _, ok := ctx.Deadline(); !ok || client.Timeout == 0 {
+		return client
+	}
+	override := *client
+	override.Timeout = 0
+	return &override
+}